@@ -1,19 +1,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
 	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
 	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
 var branchNameFlag string // Variable to hold the value of --branch flag
+var addRemoteFlag bool    // Variable to hold the value of --remote flag
+var addCreateFlag bool
+var addBaseFlag string
+var addAllowMissingFlag bool
+var addGitTagFlag string
+var addCommitFlag string
+var addRefFlag string
 
 var addCmd = &cobra.Command{
 	Use:   "add [branch-alias]",
@@ -28,12 +35,35 @@ If no alias is provided, the branch name will be used as the alias.
 
 Usage:
   gitbm add [branch-alias] [--branch <branch-name>]
+  gitbm add [alias] --git-tag <tag> | --commit <commit> | --ref <ref>
 
 Examples:
   gitbm add                        # Adds the current branch
   gitbm add "Feature X"             # Adds the current branch with an alias
   gitbm add --branch feature/1234   # Adds a specific branch
   gitbm add --branch feature/1234 "Alias for Feature X"
+  gitbm add --branch origin/feature/5678  # Adds a branch that only exists on a remote
+  gitbm add --remote                      # Pick a remote-only branch with the fuzzy finder
+
+Remote-tracking branches (eg. origin/feature/5678), and branches that only exist on a remote,
+are bookmarked under their local name along with the remote they come from. Checking out such
+a bookmark creates the local tracking branch first (git switch --track).
+
+The branch given with --branch must exist. If it does not, the closest branch names are offered
+in the fuzzy finder, in case of a typo. Use --create to create the branch instead, starting at
+--base (default HEAD), or --allow-missing to bookmark it anyway.
+
+  gitbm add --branch feature/9012 --create --base main
+  gitbm add --branch not-pushed-yet --allow-missing
+
+Git tags, commits and other refs (eg. bisect points) can be bookmarked too, and are checked out
+as a detached HEAD. Commits are bookmarked by their full object name, with their subject as
+the default alias. When HEAD is detached, gitbm add offers to bookmark the tags pointing at
+it or the commit itself, as there is no current branch.
+
+  gitbm add --git-tag v1.2.0 "Last release"
+  gitbm add --commit HEAD~3 "Before the refactoring"
+  gitbm add --ref refs/bisect/bad
 
 Note:
 - This command must be run from within a Git repository.
@@ -44,79 +74,240 @@ The command will:
 1. Get the current Git branch name or use the provided branch name.
 2. Use the provided alias or the branch name if no alias is given.
 3. Add the branch to the active bookmark group in the database.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Validate basic requirements
-		err := utils.ValidateBasic()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
+		defer client.Close()
 
 		// Get branch name
 		var branchName string
-		if branchNameFlag != "" {
-			// Use the branch specified by --branch flag
-			branchName = branchNameFlag
+		var remote string
+		kind := gitbm.KindBranch
+		if addRemoteFlag {
+			// Pick one of the branches that only exist on a remote
+			remoteBranches, err := listRemoteOnlyBranches()
+			if err != nil {
+				return err
+			}
+			if len(remoteBranches) == 0 {
+				logger.PrintInfo("No remote-only branches found. Maybe run `git fetch` first?")
+				return nil
+			}
+
+			selectedBranch, err := fzfutils.FuzzyFind(
+				app.Picker,
+				remoteBranches,
+				gitutils.RemoteBranchRef.String,
+				"Select a remote branch",
+			)
+			if err != nil {
+				return fmt.Errorf("error selecting branch: %w", err)
+			}
+			branchName, remote = selectedBranch.Name, selectedBranch.Remote
+		} else if branchNameFlag != "" {
+			// Use the branch specified by --branch flag, which may be a remote-tracking branch
+			branchName, remote = resolveBranchToAdd(branchNameFlag)
+			branchExists := remote != "" || app.Repo().RefExists("refs/heads/"+branchName)
+
+			switch {
+			case addCreateFlag && branchExists:
+				return fmt.Errorf("branch %s already exists, drop --create to bookmark it", branchName)
+			case addCreateFlag:
+				if !app.Repo().RefExists(addBaseFlag) {
+					return fmt.Errorf("base '%s' does not exist", addBaseFlag)
+				}
+			case branchExists:
+			case addAllowMissingFlag:
+				logger.PrintWarning("Branch %s does not exist, bookmarking it anyway", branchName)
+			default:
+				branchName, remote, err = pickCloseBranch(branchName)
+				if err != nil {
+					return err
+				}
+			}
+		} else if addCreateFlag {
+			return errors.New("--create requires the name of the new branch in --branch")
+		} else if addGitTagFlag != "" {
+			if !app.Repo().RefExists("refs/tags/" + addGitTagFlag) {
+				return fmt.Errorf("tag %s does not exist", addGitTagFlag)
+			}
+			branchName, kind = addGitTagFlag, gitbm.KindTag
+		} else if addCommitFlag != "" {
+			branchName, err = app.Repo().ResolveRef(addCommitFlag)
+			if err != nil {
+				return fmt.Errorf("commit '%s' does not exist", addCommitFlag)
+			}
+			kind = gitbm.KindCommit
+		} else if addRefFlag != "" {
+			if !app.Repo().RefExists(addRefFlag) {
+				return fmt.Errorf("ref '%s' does not exist", addRefFlag)
+			}
+			branchName, kind = addRefFlag, gitbm.KindRef
 		} else {
 			// Get the current branch if no --branch flag is provided
-			branchName, err = gitutils.GetCurrentGitBranch()
+			branchName, err = app.Repo().GetCurrentGitBranch()
 			if err != nil {
-				logger.PrintError("Error getting current branch name: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error getting current branch name: %w", err)
+			}
+
+			// There is no current branch to bookmark on a detached HEAD
+			if branchName == "HEAD" {
+				branchName, kind, err = pickDetachedHead()
+				if err != nil {
+					return err
+				}
 			}
 		}
 
 		// Handle branch alias
 		var branchAlias string
-		if len(args) == 0 {
+		if len(args) > 0 {
+			branchAlias = args[0]
+		} else if kind == gitbm.KindCommit {
+			logger.PrintWarning("No alias specified. Using the commit subject as alias.")
+			subject, _ := app.Repo().GetCommitSubject(branchName)
+			branchAlias = strings.TrimSpace(fmt.Sprintf("%s %s", branchName[:7], subject))
+		} else {
 			logger.PrintWarning("No branch alias specified. Using branch name as alias.")
 			branchAlias = branchName
-		} else {
-			branchAlias = args[0]
 		}
 
-		// Get db connection
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbFilePath)
-
+		// Get current bookmark group
+		bookmarkGroup, err := getExplicitCurrentGroup(client)
 		if err != nil {
-			logger.PrintError("Error getting db connection: %v", err)
-			os.Exit(1)
+			return err
 		}
 
-		defer db.Close()
-
-		// Get current bookmark group id
-		currentBookmarkGrpRepo := models.NewCurrentBookmarkGroupRepository(db)
-		currentBookmarkGroupId, err := currentBookmarkGrpRepo.GetCurrentBookmarkGroupId()
-
-		if err != nil {
-			logger.PrintError("Error getting current bookmark group id: %v", err)
-			os.Exit(1)
+		// Create the branch only once it can be bookmarked
+		if addCreateFlag {
+			err = app.Repo().CreateBranch(branchName, addBaseFlag)
+			if err != nil {
+				return err
+			}
+			logger.PrintInfo("Created branch %s from %s", branchName, addBaseFlag)
 		}
 
-		branchRepo := models.NewBranchRepository(db)
-
 		// Add the branch to the db
-		branch := &models.Branch{
-			BookmarkGroupID: currentBookmarkGroupId,
-			Name:            branchName,
-			Alias:           branchAlias,
+		branch := &gitbm.Bookmark{
+			Name:   branchName,
+			Alias:  branchAlias,
+			Remote: remote,
+			Kind:   kind,
 		}
 
-		err = branchRepo.Create(branch)
+		err = client.AddBookmark(bookmarkGroup.Name, branch)
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
 
-		logger.PrintSuccess("Branch %s added successfully", branchName)
+		if remote != "" {
+			logger.PrintSuccess("Branch %s added successfully (from %s)", branchName, remote)
+			return nil
+		}
+		logger.PrintSuccess("%s %s added successfully", bookmarkKindLabel(*branch), branch.DisplayName())
+		return nil
 	},
 }
 
+// A way to bookmark a detached HEAD: one of the tags pointing at it, or the commit
+type detachedHeadChoice struct {
+	name    string
+	kind    string
+	display string
+}
+
+// Offers the tags pointing at the detached HEAD and the commit itself in the fuzzy finder,
+// returning the name and kind of the bookmark to add
+func pickDetachedHead() (string, string, error) {
+	commit, err := app.Repo().ResolveRef("HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	tags, err := app.Repo().ListTags("HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	subject, _ := app.Repo().GetCommitSubject(commit)
+
+	var choices []detachedHeadChoice
+	for _, tag := range tags {
+		choices = append(choices, detachedHeadChoice{name: tag, kind: gitbm.KindTag, display: "tag " + tag})
+	}
+	choices = append(choices, detachedHeadChoice{
+		name:    commit,
+		kind:    gitbm.KindCommit,
+		display: fmt.Sprintf("commit %s %s", commit[:7], subject),
+	})
+
+	logger.PrintWarning("HEAD is detached, pick what to bookmark instead of a branch:")
+	selected, err := fzfutils.FuzzyFind(
+		app.Picker,
+		choices,
+		func(c detachedHeadChoice) string { return c.display },
+		"Select what to bookmark",
+	)
+	if err != nil {
+		return "", "", err
+	}
+	return selected.name, selected.kind, nil
+}
+
+// Offers the branches closest to a branch name that does not exist in the fuzzy finder,
+// returning the picked branch as resolved by resolveBranchToAdd
+func pickCloseBranch(name string) (string, string, error) {
+	localBranches, err := app.Repo().ListBranches()
+	if err != nil {
+		return "", "", err
+	}
+	remoteOnly, err := listRemoteOnlyBranches()
+	if err != nil {
+		return "", "", err
+	}
+
+	candidates := make([]string, 0, len(localBranches)+len(remoteOnly))
+	for _, b := range localBranches {
+		candidates = append(candidates, b.Name)
+	}
+	for _, b := range remoteOnly {
+		candidates = append(candidates, b.String())
+	}
+
+	matches := utils.CloseMatches(name, candidates, 10)
+	if len(matches) == 0 {
+		return "", "", fmt.Errorf("branch '%s' does not exist, use --create to create it or --allow-missing to bookmark it anyway", name)
+	}
+
+	logger.PrintWarning("Branch %s does not exist, did you mean one of these?", name)
+	selected, err := fzfutils.FuzzyFind(
+		app.Picker,
+		matches,
+		func(s string) string { return s },
+		"Select a branch",
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	branchName, remote := resolveBranchToAdd(selected)
+	return branchName, remote, nil
+}
+
 func init() {
 	// Register the --branch (-b) flag
 	addCmd.Flags().StringVarP(&branchNameFlag, "branch", "b", "", "Specify a branch name to bookmark (default is current branch)")
+	addCmd.Flags().BoolVarP(&addRemoteFlag, "remote", "r", false, "Pick a branch that only exists on a remote")
+	addCmd.Flags().BoolVar(&addCreateFlag, "create", false, "Create the branch given with --branch")
+	addCmd.Flags().StringVar(&addBaseFlag, "base", "HEAD", "Starting point of the branch created with --create")
+	addCmd.Flags().BoolVar(&addAllowMissingFlag, "allow-missing", false, "Bookmark the branch given with --branch even if it does not exist")
+	addCmd.Flags().StringVar(&addGitTagFlag, "git-tag", "", "Bookmark a git tag, checked out as a detached HEAD")
+	addCmd.Flags().StringVar(&addCommitFlag, "commit", "", "Bookmark a commit, checked out as a detached HEAD")
+	addCmd.Flags().StringVar(&addRefFlag, "ref", "", "Bookmark any ref or revision (eg. refs/bisect/bad), checked out as a detached HEAD")
+	addCmd.MarkFlagsMutuallyExclusive("branch", "remote", "git-tag", "commit", "ref")
+	addCmd.MarkFlagsMutuallyExclusive("create", "allow-missing")
+	addCmd.RegisterFlagCompletionFunc("branch", completeGitBranches)
+	addCmd.RegisterFlagCompletionFunc("base", completeGitBranches)
+	addCmd.RegisterFlagCompletionFunc("git-tag", completeGitTags)
 	rootCmd.AddCommand(addCmd)
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+)
+
+// What the commands run against. It is set up by the root command before any command runs,
+// and every field left empty gets its default, so tests can drive the CLI in-process with
+// their own repository, database, git backend and picker (see ExecuteWith).
+type App struct {
+	Dir    string           // Working tree of the repository, the current directory if empty
+	DB     *sql.DB          // Database used instead of the database file of the repository
+	Git    gitbm.GitBackend // Reads the repository instead of the gitbm.gitBackend git config
+	Picker Picker           // Lets the user pick from a list, the fuzzy finder if nil
+	repo   *gitutils.Repo   // Git repository at Dir, for the git operations that are not part of the client
+}
+
+// Lets the user pick one of items, returning its index, or ErrSelectionCancelled
+// if the user cancels. Set App.Picker to script the picks, eg. in tests.
+type Picker = fzfutils.Picker
+
+// Options of a pick, eg. the preview of the highlighted item
+type PickOptions = fzfutils.Options
+
+// Returned by a Picker when the user cancels the selection
+var ErrSelectionCancelled = fzfutils.ErrSelectionCancelled
+
+// App of the running command
+var app = &App{}
+
+// Fill in the defaults of the fields left empty
+func (a *App) setup() error {
+	if a.Dir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("error getting current directory: %w", err)
+		}
+		a.Dir = dir
+	}
+
+	a.repo = gitutils.NewRepo(a.Dir)
+	if a.Git != nil {
+		a.repo = gitutils.NewRepoWithBackend(a.Dir, a.Git)
+	}
+
+	if a.Picker == nil {
+		a.Picker = fzfutils.Fzf{}
+	}
+	return nil
+}
+
+// Options opening the gitbm client on the database and git backend of the app
+func (a *App) options() []gitbm.Option {
+	var opts []gitbm.Option
+	if a.DB != nil {
+		opts = append(opts, gitbm.WithDB(a.DB))
+	}
+	if a.Git != nil {
+		opts = append(opts, gitbm.WithGitBackend(a.Git))
+	}
+	return opts
+}
+
+// Open the gitbm client of the repository, it must be closed
+func (a *App) Client() (*gitbm.Client, error) {
+	return gitbm.Open(a.Dir, a.options()...)
+}
+
+// Initialize gitbm in the repository, see gitbm.Init
+func (a *App) Init(tracking string) (*gitbm.Client, error) {
+	return gitbm.Init(a.Dir, tracking, a.options()...)
+}
+
+// Restore a backup of the database of the app, see gitbm.Restore
+func (a *App) Restore(id string) (*gitbm.Client, *gitbm.Backup, error) {
+	return gitbm.Restore(a.Dir, id, a.options()...)
+}
+
+// List the backups of the repository, see gitbm.Backups
+func (a *App) Backups() ([]gitbm.Backup, error) {
+	return gitbm.Backups(a.Dir)
+}
+
+// Git repository of the app
+func (a *App) Repo() *gitutils.Repo {
+	return a.repo
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/devadathanmb/gitbm/internal/testutil"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/fatih/color"
+)
+
+// Picks the given items in turn, an empty one cancelling the selection
+type scriptedPicker struct {
+	t     *testing.T
+	picks []string
+}
+
+func (p *scriptedPicker) Pick(items []string, prompt string, opts PickOptions) (int, error) {
+	if len(p.picks) == 0 {
+		p.t.Fatalf("unexpected pick '%s' from %v", prompt, items)
+	}
+	pick := p.picks[0]
+	p.picks = p.picks[1:]
+	if pick == "" {
+		return -1, ErrSelectionCancelled
+	}
+
+	i := slices.Index(items, pick)
+	if i < 0 {
+		p.t.Fatalf("'%s' is not one of %v to pick from for '%s'", pick, items, prompt)
+	}
+	return i, nil
+}
+
+// Switch to a bookmark group and bookmark a branch in it by picking them, as a user would
+func TestSwitchAddList(t *testing.T) {
+	dir := testutil.NewRepo(t, "feature/login", "feature/signup")
+	database := newTestDB(t)
+	picker := &scriptedPicker{t: t}
+
+	run := func(args ...string) string {
+		t.Helper()
+		output, err := execute(&App{Dir: dir, DB: database, Picker: picker}, args...)
+		if err != nil {
+			t.Fatalf("gitbm %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+		return output
+	}
+
+	run("init", "--tracking=none")
+	run("create", "backend")
+	run("create", "frontend")
+
+	picker.picks = []string{"backend"}
+	run("switch")
+
+	// A typo in the branch name offers the closest branches
+	picker.picks = []string{"feature/login"}
+	run("add", "--branch", "feature/logn", "Login page")
+
+	output := run("list", "branches")
+	if !strings.Contains(output, "feature/login") || !strings.Contains(output, "Login page") {
+		t.Errorf("feature/login is not listed:\n%s", output)
+	}
+	if len(picker.picks) > 0 {
+		t.Errorf("%v were not picked", picker.picks)
+	}
+
+	client, err := gitbm.Open(dir, gitbm.WithDB(database))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	bookmarks, err := client.Bookmarks("backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Name != "feature/login" || bookmarks[0].Alias != "Login page" {
+		t.Errorf("bookmarks of backend are %+v, want feature/login as Login page", bookmarks)
+	}
+	if bookmarks, _ := client.Bookmarks("frontend"); len(bookmarks) != 0 {
+		t.Errorf("bookmarks of frontend are %+v, want none", bookmarks)
+	}
+}
+
+// Cancelling a pick changes nothing
+func TestSwitchCancelled(t *testing.T) {
+	dir := testutil.NewRepo(t)
+	database := newTestDB(t)
+	picker := &scriptedPicker{t: t}
+
+	a := &App{Dir: dir, DB: database, Picker: picker}
+	for _, args := range [][]string{{"init", "--tracking=none"}, {"create", "backend"}, {"create", "frontend"}} {
+		if output, err := execute(a, args...); err != nil {
+			t.Fatalf("gitbm %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	picker.picks = []string{""}
+	if _, err := execute(a, "switch"); !errors.Is(err, ErrSelectionCancelled) {
+		t.Errorf("gitbm switch returned %v, want ErrSelectionCancelled", err)
+	}
+
+	client, err := gitbm.Open(dir, gitbm.WithDB(database))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if group, err := client.CurrentGroup(); err != nil || group.Name != "frontend" {
+		t.Errorf("current group is %v (%v), want frontend", group, err)
+	}
+}
+
+// Every command letting the user pick returns ErrSelectionCancelled when the pick is cancelled,
+// having changed nothing
+func TestPickCancelled(t *testing.T) {
+	dir := testutil.NewRepo(t, "feature/login")
+	database := newTestDB(t)
+	picker := &scriptedPicker{t: t}
+
+	a := &App{Dir: dir, DB: database, Picker: picker}
+	for _, args := range [][]string{{"init", "--tracking=none"}, {"create", "backend"}, {"add", "--branch", "feature/login"}} {
+		if output, err := execute(a, args...); err != nil {
+			t.Fatalf("gitbm %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"checkout"},
+		{"find"},
+		{"remove"},
+		{"delete"},
+		{"add", "--branch", "feature/logn"},
+	} {
+		picker.picks = []string{""}
+		if _, err := execute(a, args...); !errors.Is(err, ErrSelectionCancelled) {
+			t.Errorf("gitbm %s returned %v, want ErrSelectionCancelled", strings.Join(args, " "), err)
+		}
+	}
+
+	client, err := gitbm.Open(dir, gitbm.WithDB(database))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if bookmarks, err := client.Bookmarks("backend"); err != nil || len(bookmarks) != 1 {
+		t.Errorf("bookmarks of backend are %+v (%v), want feature/login only", bookmarks, err)
+	}
+	if branch, err := a.Repo().GetCurrentGitBranch(); err != nil || branch != "main" {
+		t.Errorf("checked out %s (%v), want main", branch, err)
+	}
+}
+
+// Backups of the database of the app are restored into it, not into the database file
+func TestRestoreDB(t *testing.T) {
+	dir := testutil.NewRepo(t, "feature/login")
+	database := newTestDB(t)
+
+	a := &App{Dir: dir, DB: database, Picker: &scriptedPicker{t: t}}
+	for _, args := range [][]string{
+		{"init", "--tracking=none"},
+		{"create", "backend"},
+		{"backup", "create"},
+		{"add", "--branch", "feature/login"},
+		{"restore", "latest"},
+	} {
+		if output, err := execute(a, args...); err != nil {
+			t.Fatalf("gitbm %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	client, err := gitbm.Open(dir, gitbm.WithDB(database))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if bookmarks, err := client.Bookmarks("backend"); err != nil || len(bookmarks) != 0 {
+		t.Errorf("bookmarks of backend are %+v (%v), want none once restored", bookmarks, err)
+	}
+
+	// The database replaced by the restore is backed up, in the repository
+	backups, err := a.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[1].Reason != gitbm.BackupRestore {
+		t.Errorf("backups are %+v, want the manual one and the one taken before the restore", backups)
+	}
+}
+
+// Destroying gitbm leaves the database of the app open, it belongs to the caller
+func TestDestroyDB(t *testing.T) {
+	dir := testutil.NewRepo(t)
+	database := newTestDB(t)
+
+	a := &App{Dir: dir, DB: database, Picker: &scriptedPicker{t: t}}
+	for _, args := range [][]string{{"init", "--tracking=none"}, {"destroy", "--force"}} {
+		if output, err := execute(a, args...); err != nil {
+			t.Fatalf("gitbm %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	if err := database.Ping(); err != nil {
+		t.Errorf("database is closed: %v", err)
+	}
+}
+
+// Open an in-memory database, closed at the end of the test
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.SetMaxOpenConns(1) // Every connection to :memory: opens a new database
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// Run a command against a, returning what it prints along with its error
+func execute(a *App, args ...string) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	printed := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		r.Close()
+		printed <- string(data)
+	}()
+
+	// Messages are printed by color, which holds on to the stdout it started with.
+	// They are restored even if the picker fails the test.
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	err = func() error {
+		defer func() {
+			os.Stdout, color.Output = stdout, colorOutput
+			w.Close()
+		}()
+		return ExecuteWith(a, args)
+	}()
+	return <-printed, err
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var backCmd = &cobra.Command{
+	Use:   "back [n]",
+	Short: "Go back in the branch navigation history",
+	Long: `
+Checkout the branch visited n steps back in the navigation history (default 1).
+
+gitbm keeps a browser-style navigation history of the branches you check out,
+recorded by the post-checkout hook. Unlike 'git checkout -', which only remembers
+one step, you can walk back and forth through it with 'gitbm back' and 'gitbm forward'.
+Checking out any branch in between drops the entries ahead, like following a link in a browser.
+
+If fewer than n steps are available, it goes back as far as possible.
+
+Usage:
+  gitbm back [n]
+
+Examples:
+  gitbm back
+  gitbm back 3
+
+See 'gitbm history' for the navigation history.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return navigate(args, false)
+	},
+}
+
+// Moves the given number of steps (args[0], default 1) through the navigation history
+func navigate(args []string, forward bool) error {
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	steps := 1
+	if len(args) > 0 {
+		steps, err = strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return fmt.Errorf("invalid number of steps: %s", args[0])
+		}
+	}
+
+	direction := "back"
+	if forward {
+		direction = "forward"
+	}
+
+	entry, taken, err := client.Jump(steps, forward)
+	if errors.Is(err, gitbm.ErrNoHistory) {
+		logger.PrintInfo("Nothing to go %s to.", direction)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if taken < steps {
+		logger.PrintWarning("Only %d step(s) available, went %s %d step(s).", taken, direction, taken)
+	}
+
+	logger.PrintInfo("Checked out to branch: %s", entry.BranchName)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(backCmd)
+}
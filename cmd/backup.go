@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage the backups of the gitbm database",
+	Long: `
+Manage the backups of the gitbm database, stored in .git/gitbm-backups.
+
+'gitbm destroy', 'gitbm delete' and 'gitbm reset checkouts' back up the database before
+removing anything, with sqlite's online backup API, so the copy is consistent even while
+the hook or gitbm watch record checkouts. Restoring a backup backs up the database it
+replaces first, so a restore can be undone too.
+
+Only the most recent backups are kept: 10 by default, set the gitbm.backupRetention git
+config to change it.
+
+Usage:
+  gitbm backup list
+  gitbm backup create
+  gitbm backup restore <id | latest>
+
+Examples:
+  gitbm backup list
+  gitbm backup create
+  gitbm backup restore latest
+  git config gitbm.backupRetention 30
+
+Note: This command must be run from within a Git repository.`,
+}
+
+// Backs up the database before a command removes data from it
+func backupBefore(client *gitbm.Client, reason string) error {
+	backup, err := client.Backup(reason)
+	if err != nil {
+		return fmt.Errorf("error backing up the database, nothing was changed: %w", err)
+	}
+	logger.PrintInfo("Backed up the database, restore it with 'gitbm restore %s'", backup.ID)
+	return nil
+}
+
+// Formats a size in bytes for humans, eg. 12.3 KiB
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+}
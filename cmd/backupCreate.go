@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up the gitbm database",
+	Long: `
+Back up the gitbm database into .git/gitbm-backups, eg. before trying something out.
+
+The oldest backups past the gitbm.backupRetention git config (10 by default) are removed.
+
+Usage:
+  gitbm backup create
+
+Example:
+  gitbm backup create
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		backup, err := client.Backup(gitbm.BackupManual)
+		if err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Created backup %s (%s), restore it with 'gitbm restore %s'", backup.ID, formatSize(backup.Size), backup.ID)
+		return nil
+	},
+}
+
+func init() {
+	backupCmd.AddCommand(backupCreateCmd)
+}
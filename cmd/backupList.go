@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the backups of the gitbm database",
+	Long: `
+List the backups of the gitbm database, newest first, with what they were taken before.
+
+Backups are listed even if gitbm is not initialized, eg. after 'gitbm destroy'.
+
+Usage:
+  gitbm backup list
+
+Example:
+  gitbm backup list
+
+Note: This command must be run from within a Git repository.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backups, err := app.Backups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			logger.PrintInfo("No backups yet. Use `gitbm backup create` to create one.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTAKEN BEFORE\tDATE\tSIZE")
+		for i := len(backups) - 1; i >= 0; i-- {
+			backup := backups[i]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", backup.ID, backup.Reason, backup.CreatedAt.Format("2006-01-02 15:04:05"), formatSize(backup.Size))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	backupCmd.AddCommand(backupListCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <id | latest>",
+	Short: "Restore a backup of the gitbm database",
+	Long: `
+Restore a backup of the gitbm database, listed by 'gitbm backup list'. Use 'latest' to
+restore the most recent one. 'gitbm restore' is a shortcut for this command.
+
+The database is backed up before it is replaced, so restoring 'latest' again undoes
+the restore. If gitbm was destroyed, it is initialized again and checkouts are tracked
+as they were (see 'gitbm init --tracking').
+
+Usage:
+  gitbm backup restore <id | latest>
+  gitbm restore <id | latest>
+
+Examples:
+  gitbm backup restore 20261019-125300
+  gitbm restore latest
+
+Note: This command must be run from within a Git repository.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeBackups,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// A destroyed repository is initialized again by the restore
+		initialized := true
+		if client, err := app.Client(); err == nil {
+			client.Close()
+		} else if errors.Is(err, gitbm.ErrNotInitialized) {
+			initialized = false
+		}
+
+		client, backup, err := app.Restore(args[0])
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if !initialized {
+			logger.PrintInfo("Initialized gitbm database")
+			if err := startTracking(client); err != nil {
+				return err
+			}
+		}
+		logger.PrintSuccess("Restored backup %s, taken before %s on %s", backup.ID, backup.Reason, backup.CreatedAt.Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+// Shortcut of gitbm backup restore
+var restoreCmd = &cobra.Command{
+	Use:               "restore <id | latest>",
+	Short:             "Restore a backup of the gitbm database, see 'gitbm backup restore'",
+	Long:              backupRestoreCmd.Long,
+	Args:              backupRestoreCmd.Args,
+	ValidArgsFunction: completeBackups,
+	RunE:              backupRestoreCmd.RunE,
+}
+
+func init() {
+	backupCmd.AddCommand(backupRestoreCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
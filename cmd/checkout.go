@@ -1,16 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
-	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -31,104 +28,88 @@ Examples:
   gitbm checkout feature-branch
   gitbm checkout  # Opens fuzzy finder
 
-The fuzzy finder allows searching by branch name or alias.
+The fuzzy finder allows searching by branch name, alias or label.
 
-Note: An active bookmark group is required.`,
-	Run: func(cmd *cobra.Command, args []string) {
+Use --tag (-t) to pick from the branches having all of the given labels,
+across every bookmark group.
 
-		// Validate basic
-		err := utils.ValidateBasic()
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
-		// Get db connection
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbFilePath)
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
+Examples:
+  gitbm checkout --tag needs-review
+  gitbm checkout -t blocked -t customer-acme
 
-		defer db.Close()
+Bookmarks of branches that only exist on a remote are checked out by
+creating a local branch tracking the remote one (git switch --track).
 
-		// Get current bookmark group id
-		currentBookmarkGrpRepo := models.NewCurrentBookmarkGroupRepository(db)
-		currentBookmarkGroupId, err := currentBookmarkGrpRepo.GetCurrentBookmarkGroupId()
+Note: An active bookmark group is required, unless filtering by labels.`,
+	ValidArgsFunction: completeBranches,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
-		if currentBookmarkGroupId == 0 {
-			logger.PrintInfo("No bookmark group set.")
-			return
+			return err
 		}
+		defer client.Close()
 
-		var branchName string
+		var branch gitbm.Bookmark
 
 		// Get the branches in the current bookmark group
-		branchRepo := models.NewBranchRepository(db)
 		if len(args) > 0 {
-			branchName = args[0]
-
 			// Validate if the branch exists in the current bookmark group
-			branch := &models.Branch{Name: branchName, BookmarkGroupID: currentBookmarkGroupId}
-			_, err := branchRepo.GetByName(currentBookmarkGroupId, branch.Name)
+			groupBranch, err := client.Bookmark("", args[0])
+			if errors.Is(err, gitbm.ErrNoCurrentGroup) {
+				logger.PrintInfo("No bookmark group set.")
+				return nil
+			}
 			if err != nil {
-				logger.PrintError(fmt.Sprint(err))
-				os.Exit(1)
+				return err
 			}
+			branch = *groupBranch
 		} else {
-			branch := &models.Branch{BookmarkGroupID: currentBookmarkGroupId}
-			branches, err := branchRepo.ListByBookmarkGroupId(branch.BookmarkGroupID)
+			branches, displayFunc, err := listBranchesToPick(client)
+			if errors.Is(err, gitbm.ErrNoCurrentGroup) {
+				logger.PrintInfo("No bookmark group set.")
+				return nil
+			}
 			if err != nil {
-				logger.PrintError(fmt.Sprint(err))
-				os.Exit(1)
+				return err
 			}
 
 			if len(branches) == 0 {
-				logger.PrintInfo("No branches in the current bookmark group.")
-				return
+				if len(tagFilterFlag) > 0 {
+					logger.PrintInfo("No branches with the given labels.")
+				} else {
+					logger.PrintInfo("No branches in the current bookmark group.")
+				}
+				return nil
 			}
 
 			// fzf the branches
 			selectedBranch, err := fzfutils.FuzzyFind(
+				app.Picker,
 				branches,
-				func(b models.Branch) string {
-					if b.Alias != "" {
-						return fmt.Sprintf("%s -- %s", b.Name, b.Alias)
-					}
-					return b.Name
-				},
+				displayFunc,
 				"Select a branch",
+				fzfutils.WithPreview(branches, formatBranchPreview),
 			)
 			if err != nil {
-				if err == fzfutils.ErrSelectionCancelled {
-					logger.PrintInfo("Branch selection cancelled")
-					os.Exit(1)
-				}
-				logger.PrintError("Error selecting branch: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error selecting branch: %w", err)
 			}
 
-			branchName = selectedBranch.Name
+			branch = selectedBranch
 		}
 
-		// Now git checkout to the branch
-		err = gitutils.GitCheckout(branchName)
+		// Now git checkout to the branch, creating it from its remote if needed
+		err = checkoutBookmark(client, branch)
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
 
-		logger.PrintInfo("Checked out to branch: %s", branchName)
-
+		logger.PrintInfo("Checked out to %s: %s", strings.ToLower(bookmarkKindLabel(branch)), branch.DisplayName())
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(checkoutCmd)
+	checkoutCmd.Flags().StringSliceVarP(&tagFilterFlag, "tag", "t", nil, "Only show branches with these labels, across all bookmark groups")
+	checkoutCmd.RegisterFlagCompletionFunc("tag", completeTags)
 }
@@ -1,14 +1,8 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +12,8 @@ var checkoutsCmd = &cobra.Command{
 	Short: "Reset the branch checkouts data",
 	Long: `The 'reset checkouts' command resets the branch checkouts data in the repository.
 
-It clears all the branch checkouts data stored in the database. This action is irreversible.
+It clears all the branch checkouts data stored in the database. The database is backed up
+first, restore it with 'gitbm restore latest' (see 'gitbm backup').
 
 By default, it shows the top 10 most frequently used branches. You can modify this behavior
 using the available flags.
@@ -31,35 +26,24 @@ Examples:
   gitbm reset checkouts
 `,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		// Validate basic
-		err := utils.ValidateBasic()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
-
-		// Get the db connection
-		cwd, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(cwd)
-		db, err := db.GetDB(dbPath)
-
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
-		defer db.Close()
+		defer client.Close()
 
 		// Remove all checkouts
-		checkoutsRepo := models.NewBranchCheckoutRepository(db)
-		err = checkoutsRepo.DeleteAll()
+		if err := backupBefore(client, gitbm.BackupResetCheckouts); err != nil {
+			return err
+		}
+		err = client.ResetCheckouts()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
 
 		logger.PrintSuccess("Checkouts data has been reset")
+		return nil
 	},
 }
 
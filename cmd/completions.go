@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+// Opens the gitbm client for shell completion.
+// Completion must never print anything, so errors are only returned to the caller.
+func getCompletionClient() (*gitbm.Client, error) {
+	return app.Client()
+}
+
+// Completes bookmark group names
+func completeBookmarkGroups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	client, err := getCompletionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer client.Close()
+
+	bookmarkGroups, err := client.Groups()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0, len(bookmarkGroups))
+	for _, bg := range bookmarkGroups {
+		completions = append(completions, bg.Name)
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Completes branch names in the current bookmark group, with aliases as descriptions
+func completeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	client, err := getCompletionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer client.Close()
+
+	branches, err := client.Bookmarks("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0, len(branches))
+	for _, b := range branches {
+		if b.Alias != "" && b.Alias != b.Name {
+			completions = append(completions, fmt.Sprintf("%s\t%s", b.Name, b.Alias))
+		} else {
+			completions = append(completions, b.Name)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Completes the names of local branches and of remote-tracking branches without a local branch
+func completeGitBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	localBranches, err := app.Repo().ListBranches()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0, len(localBranches))
+	for _, b := range localBranches {
+		completions = append(completions, b.Name)
+	}
+
+	remoteOnly, err := listRemoteOnlyBranches()
+	if err == nil {
+		for _, b := range remoteOnly {
+			completions = append(completions, fmt.Sprintf("%s\tremote", b))
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Completes the names of tags
+func completeGitTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	tags, err := app.Repo().ListTags("")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return tags, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Completes the names of the branch naming templates, with the templates as descriptions
+func completeTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	templates := app.Repo().ListGitConfig("gitbm.template.")
+
+	var completions []string
+	for _, name := range listTemplateNames(templates) {
+		completions = append(completions, fmt.Sprintf("%s\t%s", name, templates[name]))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Completes existing labels
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	client, err := getCompletionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer client.Close()
+
+	tags, err := client.Tags()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		completions = append(completions, fmt.Sprintf("%s\t%d bookmarks", tag.Name, tag.Count))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Completes backup IDs, newest first, with what they were taken before as descriptions.
+// It works after destroy, so it does not need an initialized repository.
+func completeBackups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	backups, err := app.Backups()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := []string{"latest\tthe most recent backup"}
+	for i := len(backups) - 1; i >= 0; i-- {
+		completions = append(completions, fmt.Sprintf("%s\tbefore %s", backups[i].ID, backups[i].Reason))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// Wraps a completion function so that it also completes flag values,
+// where positional args are irrelevant
+func completeFlagValue(complete func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return complete(cmd, nil, toComplete)
+	}
+}
@@ -1,15 +1,9 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
 	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
-	"github.com/mattn/go-sqlite3"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -23,20 +17,30 @@ Create a new bookmark group for the current Git project.
 This command allows you to create a named collection of branch bookmarks. 
 If no name is provided, it will use a random name.
 
+A smart bookmark group can be created by passing one of the rule flags.
+Instead of holding explicitly added branches, smart groups are evaluated live
+against the local branches whenever they are listed or picked from.
+
 Examples:
   gitbm create
   gitbm create migrate-to-kafka
   gitbm create "Feature: Add user profile"
+  gitbm create jira-123 --glob 'JIRA-123*'
+  gitbm create mine --glob 'users/me'
+  gitbm create fixes --regex '^(fix|hotfix)/'
+  gitbm create done --merged main
+  gitbm create wip --unmerged main
+  gitbm create by-me --author me@example.com
 
 The newly created bookmark group becomes the active group.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		var bookmarkGroupName string
 
-		err := utils.ValidateBasic()
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
+		defer client.Close()
 
 		if len(args) == 0 {
 			logger.PrintWarning("No bookmark group name specified. Using a random name.")
@@ -46,49 +50,46 @@ The newly created bookmark group becomes the active group.`,
 			bookmarkGroupName = args[0]
 		}
 
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-
-		db, err := db.GetDB(dbFilePath)
-
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+		// A rule flag makes it a smart bookmark group
+		var ruleType, rule string
+		for _, smartGroupRuleType := range smartGroupRuleTypes {
+			if cmd.Flags().Changed(smartGroupRuleType) {
+				ruleType = smartGroupRuleType
+				rule, _ = cmd.Flags().GetString(smartGroupRuleType)
+			}
 		}
 
-		defer db.Close()
-
-		bookmarkGroupRepo := models.NewBookmarkGroupRepository(db)
 		// Create a new bookmark group
-		bg := models.BookmarkGroup{
-			Name: bookmarkGroupName,
-		}
-
-		err = bookmarkGroupRepo.Create(&bg)
+		bg, err := client.CreateGroup(bookmarkGroupName, ruleType, rule)
 		if err != nil {
-			if sqliteErr, ok := err.(sqlite3.Error); ok {
-				if sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
-					logger.PrintError("Bookmark group with the same name already exists")
-					os.Exit(1)
-				} else {
-					logger.PrintError("Error creating bookmark group: %v", err)
-					os.Exit(1)
-				}
-			} else {
-				logger.PrintError("Error creating bookmark group: %v", err)
-				os.Exit(1)
-			}
+			return err
 		}
 
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+		if bg.IsSmart() {
+			logger.PrintSuccess("Smart bookmark group created: %s (%s)", bookmarkGroupName, gitbm.DescribeRule(*bg))
+			return nil
 		}
 
 		logger.PrintSuccess("Bookmark group created: %s", bookmarkGroupName)
+		return nil
 	},
 }
 
+// Rule types of smart bookmark groups, each one has a flag of the same name
+var smartGroupRuleTypes = []string{
+	gitbm.RuleGlob,
+	gitbm.RuleRegex,
+	gitbm.RuleMerged,
+	gitbm.RuleUnmerged,
+	gitbm.RuleAuthor,
+}
+
 func init() {
 	rootCmd.AddCommand(createCmd)
+	createCmd.Flags().String(gitbm.RuleGlob, "", "Create a smart group of the branches matching a glob, eg. 'JIRA-123*' or 'users/me'")
+	createCmd.Flags().String(gitbm.RuleRegex, "", "Create a smart group of the branches matching a regular expression")
+	createCmd.Flags().String(gitbm.RuleMerged, "", "Create a smart group of the branches merged into a ref")
+	createCmd.Flags().String(gitbm.RuleUnmerged, "", "Create a smart group of the branches not merged into a ref")
+	createCmd.Flags().String(gitbm.RuleAuthor, "", "Create a smart group of the branches whose last commit author name or email contains a string")
+	createCmd.MarkFlagsMutuallyExclusive(smartGroupRuleTypes...)
 }
@@ -1,14 +1,12 @@
 package cmd
 
 import (
-	"os"
+	"errors"
+	"fmt"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -21,42 +19,33 @@ var deleteCmd = &cobra.Command{
 Delete an existing bookmark group from the current Git project.
 This command removes a specified bookmark group and all its associated branch bookmarks. 
 If no group name is provided, an interactive selection using fzf will be presented.
-Warning: This action is irreversible. All bookmarks in the deleted group will be lost.
+The database is backed up first, restore it with 'gitbm restore latest' (see 'gitbm backup').
 Examples:
   gitbm delete old-feature
   gitbm delete "Completed Tasks"
   gitbm delete -g current
   gitbm delete (for interactive selection)
 If the deleted group was the active group, no group will be active after deletion.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := utils.ValidateBasic(); err != nil {
-			logger.PrintError("%v", err)
-			os.Exit(1)
-		}
-
-		currentDir, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbPath)
+	ValidArgsFunction: completeBookmarkGroups,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError("Error getting db connection: %v", err)
-			os.Exit(1)
+			return err
 		}
-		defer db.Close()
+		defer client.Close()
 
-		bookmarkGroupRepo := models.NewBookmarkGroupRepository(db)
 		var bookmarkGroupName string
 
 		if cmd.Flags().Changed("group") {
 			if bookmarkGroupNameFlag == "current" {
 				logger.PrintInfo("Using current bookmark group.")
-				bookmarkGroup, err := bookmarkGroupRepo.GetCurrent()
-				if err != nil {
-					logger.PrintError("Error getting current bookmark group: %v", err)
-					os.Exit(1)
-				}
-				if bookmarkGroup == nil {
+				bookmarkGroup, err := client.CurrentGroup()
+				if errors.Is(err, gitbm.ErrNoCurrentGroup) {
 					logger.PrintInfo("No bookmark group set. Use `gitbm switch` to switch bookmark group.")
-					os.Exit(0)
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("error getting current bookmark group: %w", err)
 				}
 				bookmarkGroupName = bookmarkGroup.Name
 			} else {
@@ -66,39 +55,41 @@ If the deleted group was the active group, no group will be active after deletio
 			bookmarkGroupName = args[0]
 		} else {
 			// No group specified, use fzf to select
-			bookmarkGroupsList, err := bookmarkGroupRepo.List()
+			bookmarkGroupsList, err := client.Groups()
 			if err != nil {
-				logger.PrintError("Error getting bookmark groups: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error getting bookmark groups: %w", err)
 			}
 			if len(bookmarkGroupsList) == 0 {
 				logger.PrintInfo("No bookmark groups found. Use `gitbm add` to add a bookmark group.")
-				os.Exit(0)
+				return nil
 			}
 
 			selected, err := fzfutils.FuzzyFind(
+				app.Picker,
 				bookmarkGroupsList,
-				func(bg models.BookmarkGroup) string { return bg.Name },
+				func(bg gitbm.Group) string { return bg.Name },
 				"Select a bookmark group to delete",
 			)
 			if err != nil {
-				if err == fzfutils.ErrSelectionCancelled {
-					logger.PrintInfo("Selection cancelled")
-					os.Exit(0)
-				}
-				logger.PrintError("Error in fuzzy selection: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error in fuzzy selection: %w", err)
 			}
 			bookmarkGroupName = selected.Name
 		}
 
-		err = bookmarkGroupRepo.Delete(bookmarkGroupName)
+		if _, err := client.Group(bookmarkGroupName); err != nil {
+			return fmt.Errorf("error deleting bookmark group: %w", err)
+		}
+		if err := backupBefore(client, gitbm.BackupDelete); err != nil {
+			return err
+		}
+
+		err = client.DeleteGroup(bookmarkGroupName)
 		if err != nil {
-			logger.PrintError("Error deleting bookmark group: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("error deleting bookmark group: %w", err)
 		}
 
 		logger.PrintSuccess("Bookmark group '%s' deleted successfully", bookmarkGroupName)
+		return nil
 	},
 }
 
@@ -106,4 +97,5 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 	deleteCmd.Flags().StringVarP(&bookmarkGroupNameFlag, "group", "g", "", "Remove the specified bookmark group")
 	deleteCmd.Flag("group").NoOptDefVal = "current"
+	deleteCmd.RegisterFlagCompletionFunc("group", completeFlagValue(completeBookmarkGroups))
 }
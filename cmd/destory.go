@@ -4,13 +4,10 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
-	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -22,70 +19,71 @@ var destroyCmd = &cobra.Command{
 	Long: `
 Completely remove all gitbm data from the current Git repository.
 
-WARNING: This action is irreversible. It will delete all bookmark groups,
-branch bookmarks, and any other data created by gitbm in this repository.
+WARNING: It will delete all bookmark groups, branch bookmarks, and any other data
+created by gitbm in this repository. The database is backed up first, restore it with
+'gitbm restore latest' (see 'gitbm backup').
 
 This command:
+- Backs up the gitbm database into .git/gitbm-backups
 - Deletes the gitbm database file
 - Removes all stored bookmark groups and their associated branches
 - Resets any gitbm-related configurations
+- Stops gitbm watch if it is running
 
 By default, this command will prompt for confirmation before proceeding.
 Use the -f or --force flag to bypass the confirmation prompt.
 
-Use this command with extreme caution, typically when you want to start 
-fresh or remove gitbm entirely from your project.
+Use this command with caution, typically when you want to start fresh or remove
+gitbm entirely from your project. To get rid of the backups too, remove
+.git/gitbm-backups.
 
 Examples:
   gitbm destroy
   gitbm destroy -f`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if !forceDestroy {
-			fmt.Print("Are you sure you want to destroy all gitbm data? This action cannot be undone. (Y/N): ")
+			fmt.Print("Are you sure you want to destroy all gitbm data? The database will be backed up first. (Y/N): ")
 			reader := bufio.NewReader(os.Stdin)
 			response, _ := reader.ReadString('\n')
 			response = strings.TrimSpace(strings.ToLower(response))
 
 			if response != "y" && response != "yes" {
 				fmt.Println("Operation cancelled.")
-				return
+				return nil
 			}
 		}
 
-		err := utils.ValidateBasic()
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
-
-		// Remove the database file
-		currentDir, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(currentDir)
-		err = os.Remove(dbPath)
-		if err != nil {
-			fmt.Println("Error removing gitbm database:", err)
-			return
+		defer client.Close() // Destroy closes it, unless something fails first
+		if err := backupBefore(client, gitbm.BackupDestroy); err != nil {
+			return err
 		}
 
-		logger.PrintInfo("Removed gitbm database")
-
-		// Remove the gitbm hook
-		hooksDir, err := gitutils.GetGitHooksDir()
+		// Stop the watcher first, it would record checkouts into the removed database
+		stopped, err := app.stopWatchDaemon()
 		if err != nil {
-			fmt.Println("Error getting git hooks directory:", err)
-			os.Exit(1)
+			return err
+		}
+		if stopped {
+			logger.PrintInfo("Stopped gitbm watch")
 		}
-		err = os.Remove(filepath.Join(hooksDir, "post-checkout"))
+		os.Remove(app.watchLogPath())
 
+		// Remove the database file and the gitbm hook
+		err = client.Destroy()
 		if err != nil {
-			fmt.Println("Error removing gitbm hook:", err)
-			return
+			return err
 		}
 
+		logger.PrintInfo("Removed gitbm database")
 		logger.PrintInfo("Removed gitbm hooks")
 
 		logger.PrintWarning("Now I'm become death, the destroyer of worlds. ☠️")
 		logger.PrintSuccess("gitbm data has been successfully destroyed.")
+		return nil
 	},
 }
 
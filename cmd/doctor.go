@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var doctorFixFlag bool
+var doctorHookLogFlag bool
+var doctorLimitFlag int
+
+// Failures of the hook older than this are not reported by gitbm doctor
+const recentHookFailures = 7 * 24 * time.Hour
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [--fix | --hook-log]",
+	Short: "Check the gitbm setup of the repository, and repair it",
+	Long: `
+Check that gitbm works in the current Git repository, instead of starting over with
+'gitbm destroy' when something breaks. It checks:
+- How checkouts are tracked (see 'gitbm init --tracking')
+- With the hook: that git runs hooks from where the hook is installed (core.hooksPath),
+  that the hook is installed and up to date, and that the hook finds gitbm on its PATH,
+  which may differ from the PATH of your terminal, eg. when git is run by an IDE
+- With gitbm watch: that the watcher is running
+- The recent failures of the hook
+- The integrity and the foreign keys of the database
+- That the current bookmark group exists
+- That the bookmarked branches exist
+
+Use --fix to repair what can be repaired without losing data: install or update the
+hook, restart the watcher, delete rows referencing deleted rows and switch away from
+a deleted current group. Bookmarks of deleted branches are left to 'gitbm prune'.
+
+The hook runs on every checkout and prints nothing, its failures are appended to
+.git/gitbm-hook.log instead (rotated once it grows over 1 MiB). Use --hook-log to
+show the most recent ones. To see what the hook does on a checkout, set GITBM_VERBOSE:
+  GITBM_VERBOSE=1 git checkout main
+
+Usage:
+  gitbm doctor [--fix]
+  gitbm doctor --hook-log [--limit <n>]
+
+Examples:
+  gitbm doctor
+  gitbm doctor --fix
+  gitbm doctor --hook-log --limit 50
+
+Note: This command must be run from within a Git repository initialized with gitbm.
+It exits with status 1 if it finds a problem that is left unfixed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if doctorHookLogFlag {
+			records, err := logger.ReadLogFile(app.hookLogPath())
+			if err != nil {
+				return err
+			}
+			printHookLog(records, doctorLimitFlag)
+			return nil
+		}
+
+		// The checks of the environment gitbm runs in go right after the tracking mode
+		diagnoses := client.Diagnose()
+		diagnoses = append(diagnoses[:1], append(diagnoseEnvironment(client), diagnoses[1:]...)...)
+
+		problems, fixable := 0, 0
+		for _, d := range diagnoses {
+			if d.Status != gitbm.DiagnosisOK && doctorFixFlag && d.Fix != nil {
+				if err := d.Fix(); err != nil {
+					logger.PrintError("✗ %s: %s, failed to %s: %v", d.Check, d.Detail, d.FixInfo, err)
+					problems++
+					continue
+				}
+				logger.PrintSuccess("✓ %s: fixed, %s", d.Check, d.FixInfo)
+				continue
+			}
+
+			printDiagnosis(d)
+			if d.Status == gitbm.DiagnosisOK {
+				continue
+			}
+			problems++
+			if d.Fix != nil {
+				fixable++
+			}
+		}
+
+		switch {
+		case problems == 0:
+			logger.PrintSuccess("Everything looks good 🩺")
+		case fixable > 0:
+			logger.PrintWarning("Found %d problem(s), run 'gitbm doctor --fix' to fix %d of them", problems, fixable)
+			return errReported
+		default:
+			logger.PrintWarning("Found %d problem(s)", problems)
+			return errReported
+		}
+		return nil
+	},
+}
+
+// Prints the result of a check along with how to fix it
+func printDiagnosis(d gitbm.Diagnosis) {
+	switch d.Status {
+	case gitbm.DiagnosisOK:
+		logger.PrintSuccess("✓ %s: %s", d.Check, d.Detail)
+		return
+	case gitbm.DiagnosisWarning:
+		logger.PrintWarning("! %s: %s", d.Check, d.Detail)
+	default:
+		logger.PrintError("✗ %s: %s", d.Check, d.Detail)
+	}
+
+	for _, remedy := range d.Remedies {
+		logger.Print("  - %s", remedy)
+	}
+	if d.Fix != nil {
+		logger.Print("  - Run 'gitbm doctor --fix' to %s", d.FixInfo)
+	}
+}
+
+// Checks the processes tracking checkouts: the gitbm the hook runs, or the watcher, and the hook log
+func diagnoseEnvironment(client *gitbm.Client) []gitbm.Diagnosis {
+	var diagnoses []gitbm.Diagnosis
+
+	switch client.Tracking() {
+	case gitbm.TrackingHook:
+		diagnoses = append(diagnoses, diagnoseHookPath())
+	case gitbm.TrackingWatch:
+		diagnoses = append(diagnoses, diagnoseWatch(client))
+	}
+
+	records, err := logger.ReadLogFile(app.hookLogPath())
+	if err != nil {
+		return append(diagnoses, gitbm.Diagnosis{Check: "hook log", Status: gitbm.DiagnosisError, Detail: err.Error()})
+	}
+	recent := 0
+	for _, record := range records {
+		if time.Since(record.Time) < recentHookFailures {
+			recent++
+		}
+	}
+	if recent == 0 {
+		return append(diagnoses, gitbm.Diagnosis{Check: "hook log", Status: gitbm.DiagnosisOK, Detail: "no failures in the last 7 days"})
+	}
+	return append(diagnoses, gitbm.Diagnosis{
+		Check:    "hook log",
+		Status:   gitbm.DiagnosisWarning,
+		Detail:   fmt.Sprintf("the hook failed %d time(s) in the last 7 days", recent),
+		Remedies: []string{"See why: gitbm doctor --hook-log"},
+	})
+}
+
+// Checks that the hook runs this gitbm, looking it up on the PATH of git hooks
+func diagnoseHookPath() gitbm.Diagnosis {
+	executable, err := os.Executable()
+	if err != nil {
+		return gitbm.Diagnosis{Check: "PATH", Status: gitbm.DiagnosisError, Detail: err.Error()}
+	}
+
+	found, err := app.Repo().LookPathAsHook("gitbm")
+	if err != nil {
+		return gitbm.Diagnosis{
+			Check:  "PATH",
+			Status: gitbm.DiagnosisError,
+			Detail: "the hook does not find gitbm on its PATH, checkouts are not tracked",
+			Remedies: []string{
+				fmt.Sprintf("Add %s to the PATH of the tools running git", filepath.Dir(executable)),
+				"Or track checkouts without the hook: gitbm init --tracking=watch",
+			},
+		}
+	}
+
+	if !sameFile(found, executable) {
+		return gitbm.Diagnosis{
+			Check:    "PATH",
+			Status:   gitbm.DiagnosisWarning,
+			Detail:   fmt.Sprintf("the hook runs %s, not this gitbm (%s)", found, executable),
+			Remedies: []string{"Make sure both are the same version, or remove the one you do not use"},
+		}
+	}
+	return gitbm.Diagnosis{Check: "PATH", Status: gitbm.DiagnosisOK, Detail: "the hook runs " + found}
+}
+
+// Checks that gitbm watch is running, starting it again is safe
+func diagnoseWatch(client *gitbm.Client) gitbm.Diagnosis {
+	if pid, running := app.runningWatchPid(); running {
+		return gitbm.Diagnosis{Check: "watch", Status: gitbm.DiagnosisOK, Detail: fmt.Sprintf("running (pid %d)", pid)}
+	}
+	return gitbm.Diagnosis{
+		Check:  "watch",
+		Status: gitbm.DiagnosisError,
+		Detail: "gitbm watch is not running, checkouts are not tracked",
+		Fix: func() error {
+			_, err := app.startWatchDaemon()
+			return err
+		},
+		FixInfo: "start it in the background",
+	}
+}
+
+// Checks if two paths are the same file, following symlinks
+func sameFile(a string, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// Prints the most recent records of the hook log, oldest first
+func printHookLog(records []logger.Record, limit int) {
+	if len(records) == 0 {
+		logger.PrintSuccess("No hook failures logged")
+		return
+	}
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tLEVEL\tBRANCH\tMESSAGE")
+	for _, record := range records {
+		branch, _ := record.Attrs["branch"].(string)
+		if branch == "" {
+			branch = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", record.Time.Local().Format("2006-01-02 15:04:05"), record.Level, branch, record.Message)
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFixFlag, "fix", false, "Repair the problems that can be repaired without losing data")
+	doctorCmd.Flags().BoolVar(&doctorHookLogFlag, "hook-log", false, "Show the most recent failures of the hook")
+	doctorCmd.Flags().IntVar(&doctorLimitFlag, "limit", 20, "Number of failures to show with --hook-log, 0 for all")
+	doctorCmd.MarkFlagsMutuallyExclusive("fix", "hook-log")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var switchGroupFlag bool
+
+var findCmd = &cobra.Command{
+	Use:   "find [query]",
+	Short: "Find and checkout a bookmarked branch from any bookmark group",
+	Long: `
+Find a bookmarked branch across every bookmark group and check it out.
+
+This command opens an interactive fuzzy-finder over the bookmarks of all bookmark
+groups, shown as 'group / branch -- alias'. It is handy when you forget which
+bookmark group a branch lives in.
+
+The optional query pre-fills the fuzzy-finder.
+Use --switch (-s) to also make the bookmark group of the selected branch the active group.
+
+Usage:
+  gitbm find [query] [--switch]
+
+Examples:
+  gitbm find
+  gitbm find kafka
+  gitbm find kafka --switch
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		branches, groupNames, err := listAllBookmarks(client)
+		if err != nil {
+			return fmt.Errorf("error getting bookmarks: %w", err)
+		}
+
+		if len(branches) == 0 {
+			logger.PrintInfo("No bookmarked branches found. Use `gitbm add` to add a branch.")
+			return nil
+		}
+
+		loadTickets(client, branches)
+
+		var opts []fzfutils.Option
+		opts = append(opts, fzfutils.WithPreview(branches, formatBranchPreview))
+		if len(args) > 0 {
+			opts = append(opts, fzfutils.WithQuery(args[0]))
+		}
+
+		selectedBranch, err := fzfutils.FuzzyFind(
+			app.Picker,
+			branches,
+			func(b gitbm.Bookmark) string {
+				return fmt.Sprintf("%s / %s", groupNames[b.BookmarkGroupID], formatBranch(b))
+			},
+			"Find a branch",
+			opts...,
+		)
+		if err != nil {
+			return fmt.Errorf("error selecting branch: %w", err)
+		}
+
+		err = checkoutBookmark(client, selectedBranch)
+		if err != nil {
+			return err
+		}
+
+		logger.PrintInfo("Checked out to %s: %s", strings.ToLower(bookmarkKindLabel(selectedBranch)), selectedBranch.DisplayName())
+
+		if !switchGroupFlag {
+			return nil
+		}
+
+		_, err = client.SwitchGroup(groupNames[selectedBranch.BookmarkGroupID])
+		if err != nil {
+			return fmt.Errorf("error switching bookmark group: %w", err)
+		}
+
+		logger.PrintSuccess("Bookmark group switched to: %s*", groupNames[selectedBranch.BookmarkGroupID])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(findCmd)
+	findCmd.Flags().BoolVarP(&switchGroupFlag, "switch", "s", false, "Also switch to the bookmark group of the selected branch")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward [n]",
+	Short: "Go forward in the branch navigation history",
+	Long: `
+Checkout the branch visited n steps forward in the navigation history (default 1).
+
+This undoes 'gitbm back'. The entries ahead are dropped as soon as a branch is
+checked out by any other means, like following a link in a browser.
+
+If fewer than n steps are available, it goes forward as far as possible.
+
+Usage:
+  gitbm forward [n]
+
+Examples:
+  gitbm forward
+  gitbm forward 2
+
+See 'gitbm history' for the navigation history.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return navigate(args, true)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forwardCmd)
+}
@@ -2,15 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
-	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
-	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -39,25 +33,12 @@ Examples:
 
   # List and select from the 5 least frequently used branches
   gitbm frequent --limit 5 --reverse`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Validate basic
-		err := utils.ValidateBasic()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
-
-		// Get db
-		currentDir, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbPath)
-
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
-		defer db.Close()
+		defer client.Close()
 
 		var limit int
 		var isReverse bool
@@ -65,8 +46,7 @@ Examples:
 		if cmd.Flags().Changed("limit") {
 			limit, err = cmd.Flags().GetInt("limit")
 			if err != nil {
-				logger.PrintError(fmt.Sprint(err))
-				os.Exit(1)
+				return err
 			}
 		} else {
 			limit = 10
@@ -80,15 +60,14 @@ Examples:
 
 		// Get the branch names and fzf them
 
-		branchCheckoutRepo := models.NewBranchCheckoutRepository(db)
-		branches, err := branchCheckoutRepo.GetFrequent(limit, isReverse)
+		branches, err := client.FrequentBranches(limit, isReverse)
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
 		selectedBranch, err := fzfutils.FuzzyFind(
+			app.Picker,
 			branches,
-			func(b models.BranchCheckout) string {
+			func(b gitbm.BranchStats) string {
 				if b.LatestCommitMsg != "" {
 					return fmt.Sprintf("%s -- %d -- %s", b.Name, b.CheckoutCount, b.LatestCommitMsg)
 				}
@@ -97,19 +76,14 @@ Examples:
 			"Select a branch to checkout to",
 		)
 		if err != nil {
-			if err == fzfutils.ErrSelectionCancelled {
-				logger.PrintInfo("Branch selection cancelled")
-				os.Exit(0)
-			}
-			logger.PrintError("Error selecting branch: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("error selecting branch: %w", err)
 		}
 
-		err = gitutils.GitCheckout(selectedBranch.Name)
+		err = client.CheckoutBranch(selectedBranch.Name)
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
+		return nil
 	},
 }
 
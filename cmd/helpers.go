@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+)
+
+// Returned by the commands which already printed why they failed, to exit with status 1
+var errReported = errors.New("failed")
+
+// An error along with what the user can do about it, printed by printError
+type remediesError struct {
+	err      error
+	remedies []string
+}
+
+func (e *remediesError) Error() string {
+	return e.err.Error()
+}
+
+func (e *remediesError) Unwrap() error {
+	return e.err
+}
+
+// Attaches remedies to an error, printed below it
+func withRemedies(err error, remedies ...string) error {
+	return &remediesError{err: err, remedies: remedies}
+}
+
+// Formats a bookmarked branch for the fuzzy finder
+func formatBranch(b gitbm.Bookmark) string {
+	display := b.Name
+	if b.Remote != "" {
+		display = fmt.Sprintf("%s (%s)", b.Name, b.Remote)
+	}
+	if !b.IsBranch() {
+		display = fmt.Sprintf("%s (%s)", b.DisplayName(), b.Kind)
+	}
+	if b.Alias != "" {
+		display = fmt.Sprintf("%s -- %s", display, b.Alias)
+	}
+	if len(b.Tags) > 0 {
+		display = fmt.Sprintf("%s [%s]", display, strings.Join(b.Tags, ", "))
+	}
+	if len(b.Tickets) > 0 {
+		display = fmt.Sprintf("%s {%s}", display, strings.Join(b.Tickets, ", "))
+	}
+	return display
+}
+
+// Names what a bookmark points to, eg. Branch or Tag
+func bookmarkKindLabel(b gitbm.Bookmark) string {
+	switch b.Kind {
+	case gitbm.KindTag:
+		return "Tag"
+	case gitbm.KindCommit:
+		return "Commit"
+	case gitbm.KindRef:
+		return "Ref"
+	default:
+		return "Branch"
+	}
+}
+
+// Renders the preview window of a bookmarked branch in the fuzzy finder
+func formatBranchPreview(b gitbm.Bookmark) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-7s %s\n", bookmarkKindLabel(b)+":", b.Name)
+	if b.Alias != "" {
+		fmt.Fprintf(&sb, "Alias:  %s\n", b.Alias)
+	}
+	if b.Remote != "" {
+		fmt.Fprintf(&sb, "Remote: %s\n", b.Remote)
+	}
+	if len(b.Tags) > 0 {
+		fmt.Fprintf(&sb, "Tags:   %s\n", strings.Join(b.Tags, ", "))
+	}
+	if len(b.Tickets) > 0 {
+		fmt.Fprintf(&sb, "Ticket: %s\n", strings.Join(b.Tickets, ", "))
+	}
+	if b.Notes != "" {
+		fmt.Fprintf(&sb, "\n%s\n", b.Notes)
+	}
+	return sb.String()
+}
+
+// Fills in the ticket keys found in the names of the branches.
+// Tickets are only informative, so a broken tracker config is a warning.
+func loadTickets(client *gitbm.Client, branches []gitbm.Bookmark) {
+	if err := client.LoadTickets(branches); err != nil {
+		logger.PrintWarning("Not showing tickets: %v", err)
+	}
+}
+
+// Lists the branches to pick from: either the branches of the current bookmark group
+// or, when filtering by labels, the matching branches of every bookmark group.
+// The tags and tickets of the branches are filled in, and the returned display function
+// also shows the bookmark group of each branch when filtering by labels.
+func listBranchesToPick(client *gitbm.Client) ([]gitbm.Bookmark, func(gitbm.Bookmark) string, error) {
+	var branches []gitbm.Bookmark
+	var err error
+	if len(tagFilterFlag) > 0 {
+		branches, err = client.BookmarksByTags(tagFilterFlag)
+	} else {
+		branches, err = client.Bookmarks("")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	loadTickets(client, branches)
+
+	if len(tagFilterFlag) == 0 {
+		return branches, formatBranch, nil
+	}
+
+	groupNames, err := client.GroupNames()
+	if err != nil {
+		return nil, nil, err
+	}
+	displayFunc := func(b gitbm.Bookmark) string {
+		return fmt.Sprintf("%s / %s", groupNames[b.BookmarkGroupID], formatBranch(b))
+	}
+	return branches, displayFunc, nil
+}
+
+// Gets the current bookmark group, failing for smart bookmark groups as branches can not be added to them
+func getExplicitCurrentGroup(client *gitbm.Client) (*gitbm.Group, error) {
+	bookmarkGroup, err := client.CurrentGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	if bookmarkGroup.IsSmart() {
+		return nil, fmt.Errorf("%w: %s is a smart group (%s)", gitbm.ErrSmartGroup, bookmarkGroup.Name, gitbm.DescribeRule(*bookmarkGroup))
+	}
+	return bookmarkGroup, nil
+}
+
+// Lists the bookmarked branches of every bookmark group, smart groups included,
+// along with a map of bookmark group ids to their names.
+// Smart groups whose rule fails to evaluate are skipped with a warning.
+func listAllBookmarks(client *gitbm.Client) ([]gitbm.Bookmark, map[int64]string, error) {
+	groupNames, err := client.GroupNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	branches, err := client.AllBookmarks()
+	if err != nil {
+		var smartGroupErr *gitbm.SmartGroupError
+		if !errors.As(err, &smartGroupErr) {
+			return nil, nil, err
+		}
+		logger.PrintWarning("Skipping %v", err)
+	}
+
+	return branches, groupNames, nil
+}
+
+// Checks out a bookmarked branch. When the branch does not exist locally yet but
+// exists on a remote, a local branch tracking it is created first.
+func checkoutBookmark(client *gitbm.Client, b gitbm.Bookmark) error {
+	tracked, err := client.CheckoutBookmark(b)
+	if err != nil {
+		return err
+	}
+	if tracked {
+		logger.PrintInfo("Created local branch %s tracking %s/%s", b.Name, b.Remote, b.Name)
+	}
+	return nil
+}
+
+// Resolves the name of a branch to bookmark into the local branch name and the remote
+// it comes from. Names of remote-tracking branches (eg. origin/feature/x) and branches
+// that only exist on a remote resolve to the remote; anything else is taken as local.
+func resolveBranchToAdd(name string) (string, string) {
+	if app.Repo().RefExists("refs/heads/" + name) {
+		return name, ""
+	}
+
+	remoteBranch, err := app.Repo().FindRemoteBranch(name)
+	if err != nil {
+		return name, ""
+	}
+	return remoteBranch.Name, remoteBranch.Remote
+}
+
+// Lists the remote-tracking branches which have no local branch of the same name
+func listRemoteOnlyBranches() ([]gitutils.RemoteBranchRef, error) {
+	localBranches, err := app.Repo().ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]bool, len(localBranches))
+	for _, b := range localBranches {
+		local[b.Name] = true
+	}
+
+	remoteBranches, err := app.Repo().ListRemoteBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	var remoteOnly []gitutils.RemoteBranchRef
+	for _, b := range remoteBranches {
+		if !local[b.Name] {
+			remoteOnly = append(remoteOnly, b)
+		}
+	}
+	return remoteOnly, nil
+}
+
+// Prints the error a command failed with, along with its remedies or the remedies of
+// known git failures, and with --verbose, what git printed when it failed.
+// Commands return ErrSelectionCancelled when the user cancels a selection, which is
+// reported as such; gitbm exits with status 1 as nothing was done.
+func printError(err error) {
+	if errors.Is(err, errReported) {
+		return
+	}
+	if errors.Is(err, fzfutils.ErrSelectionCancelled) {
+		logger.PrintInfo("Selection cancelled")
+		return
+	}
+
+	// Errors are wrapped in lower case, they start the message here
+	message := err.Error()
+	if message != "" {
+		message = strings.ToUpper(message[:1]) + message[1:]
+	}
+	logger.PrintError("%s", message)
+
+	remedies := gitutils.Remedies(err)
+	var remediesErr *remediesError
+	if errors.As(err, &remediesErr) {
+		remedies = append(remediesErr.remedies, remedies...)
+	}
+	for _, remedy := range remedies {
+		logger.Print("  - %s", remedy)
+	}
+
+	var commandErr *gitutils.CommandError
+	if !errors.As(err, &commandErr) {
+		return
+	}
+	if verboseFlag {
+		logger.PrintInfo("Output of git %s:", strings.Join(commandErr.Args, " "))
+		logger.Print("%s", strings.TrimRight(commandErr.Stderr, "\n"))
+	} else if len(remedies) == 0 {
+		logger.PrintInfo("Run with --verbose to see the output of git.")
+	}
+}
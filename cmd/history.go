@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the branch navigation history",
+	Long: `
+Show the navigation history walked by 'gitbm back' and 'gitbm forward', newest first.
+
+The current position is marked with '*'. The number next to each entry is the
+argument to pass to 'gitbm back' (negative) or 'gitbm forward' (positive) to get there.
+
+Usage:
+  gitbm history
+
+Example:
+  gitbm history
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		entries, cursor, err := client.NavigationHistory()
+		if err != nil {
+			return err
+		}
+
+		if len(entries) == 0 {
+			logger.PrintInfo("No navigation history yet. It is recorded as you checkout branches.")
+			return nil
+		}
+
+		// Position of the cursor, to number the entries relative to it
+		cursorIndex := len(entries) - 1
+		for i, entry := range entries {
+			if entry.ID == cursor {
+				cursorIndex = i
+			}
+		}
+
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			visitedAt := entry.VisitedAt.Local().Format("2006-01-02 15:04")
+			if i == cursorIndex {
+				logger.PrintSuccess("* %4s  %s  %s", "", visitedAt, entry.BranchName)
+				continue
+			}
+			logger.Print("  %+4d  %s  %s", i-cursorIndex, visitedAt, entry.BranchName)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
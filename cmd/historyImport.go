@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var historyImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the checkout history from the git reflog",
+	Long: `
+Bootstrap the checkout history used by 'recent', 'frequent' and 'report' from the git reflog.
+
+The HEAD reflog already remembers months of "checkout: moving from A to B" entries.
+This command imports them, with their timestamps, as if they had been tracked by the hook.
+
+Only checkouts of branches which still exist locally are imported, and only those older
+than the first checkout tracked by the hook or gitbm watch, so nothing is counted twice.
+Importing again is safe, already imported checkouts are skipped.
+
+Usage:
+  gitbm history import
+
+Example:
+  gitbm history import
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		imported, err := client.ImportReflog()
+		if err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Imported %d checkouts from the reflog", imported)
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.AddCommand(historyImportCmd)
+}
@@ -1,16 +1,19 @@
 package cmd
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
-	"github.com/devadathanmb/gitbm/internal/db"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
-	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
+var importReflogFlag bool
+var trackingFlag string
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize gitbm for the current Git repository",
@@ -24,78 +27,119 @@ branch bookmarks in the current Git repository. It creates a .gitbm.db file in t
 If gitbm is already initialized for the repository, this command will display an error.
 To reinitialize, use 'gitbm destroy' first, then run 'gitbm init' again.
 
+Use --tracking to choose how checkouts are tracked for 'recent', 'frequent', 'report'
+and back/forward navigation:
+- hook:  a post-checkout hook runs gitbm on every checkout (default)
+- watch: 'gitbm watch' follows HEAD in the background, no hook is installed
+         (see 'gitbm watch', it has to be started again after a reboot)
+- none:  checkouts are not tracked, only bookmarks are managed
+Running 'gitbm init --tracking=<mode>' again in an initialized repository switches the mode.
+
 Note: 
 - This command must be run from within a Git repository.
 - It only affects the repository in the current working directory.
 
+Use --import-reflog to bootstrap the checkout history used by 'recent', 'frequent'
+and 'report' from the git reflog (see 'gitbm history import').
+
 Example:
   cd /path/to/your/repo
-  gitbm init`,
+  gitbm init
+  gitbm init --import-reflog
+  gitbm init --tracking=watch`,
 
-	Run: func(cmd *cobra.Command, args []string) {
-		initDir, err := os.Getwd()
-		if err != nil {
-			logger.PrintError("Error getting current directory:", err)
-			os.Exit(1)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(gitbm.TrackingModes, trackingFlag) {
+			return fmt.Errorf("invalid tracking mode '%s', must be one of %s", trackingFlag, strings.Join(gitbm.TrackingModes, ", "))
 		}
 
-		isGitDir, err := utils.IsGitDir(initDir)
-		if err != nil {
-			logger.PrintError("Error checking if directory is a git repository:", err)
-			os.Exit(1)
-		}
-		if !isGitDir {
-			logger.PrintError("The specified directory is not a git repository")
-			os.Exit(1)
+		// Switch the tracking mode of an initialized repository
+		if cmd.Flags().Changed("tracking") {
+			client, err := app.Client()
+			if err == nil {
+				defer client.Close()
+				if err := setTracking(client); err != nil {
+					return err
+				}
+				logger.PrintSuccess("Checkouts are now tracked with: %s", trackingFlag)
+				return nil
+			}
+			if !errors.Is(err, gitbm.ErrNotInitialized) {
+				return err
+			}
 		}
 
-		// DB file path
-		dbFilePath := dbutils.GetDBPath(initDir)
-
-		// Validate if db file already exists
-		doesDBExist, err := utils.DoesDBExist(dbFilePath)
-
+		// Create the database and set up the tracking of checkouts
+		client, err := app.Init(trackingFlag)
 		if err != nil {
-			logger.PrintError("Error checking if database file exists:", err)
-			os.Exit(1)
+			return err
 		}
+		defer client.Close()
 
-		if doesDBExist {
-			logger.PrintError("Gitbm is already initialized for this directory")
-			os.Exit(1)
+		logger.PrintInfo("Initialized gitbm database")
+		if err := startTracking(client); err != nil {
+			return err
 		}
 
-		// Create the database file
-		err = dbutils.CreateDB(dbFilePath)
+		if importReflogFlag {
+			imported, err := client.ImportReflog()
+			if err != nil {
+				return fmt.Errorf("error importing the reflog: %w", err)
+			}
 
-		if err != nil {
-			logger.PrintError("Error creating database file:", err)
-			os.Exit(1)
+			logger.PrintInfo("Imported %d checkouts from the reflog", imported)
 		}
 
-		// Initialize the database and run migrations
-		err = db.InitDB(dbFilePath)
-		if err != nil {
-			logger.PrintError("Error initializing database:", err)
-			os.Exit(1)
-		}
-
-		logger.PrintInfo("Initialized gitbm database")
-
-		err = gitutils.InstallGitHook(initDir)
+		logger.PrintSuccess("Gitbm initialized successfully. Ready to use! 🚀")
+		return nil
+	},
+}
 
+// Switches the tracking mode to the --tracking flag, stopping or starting gitbm watch
+func setTracking(client *gitbm.Client) error {
+	if trackingFlag != gitbm.TrackingWatch {
+		stopped, err := app.stopWatchDaemon()
 		if err != nil {
-			logger.PrintError("Error installing gitbm hook:", err)
-			os.Exit(1)
+			return err
 		}
+		if stopped {
+			logger.PrintInfo("Stopped gitbm watch")
+		}
+	}
+
+	if err := client.SetTracking(trackingFlag); err != nil {
+		return err
+	}
+	if trackingFlag != gitbm.TrackingHook {
+		logger.PrintInfo("Removed gitbm hook")
+	}
+	return startTracking(client)
+}
 
+// Reports how checkouts are tracked, starting gitbm watch in the background if it tracks them
+func startTracking(client *gitbm.Client) error {
+	switch client.Tracking() {
+	case gitbm.TrackingHook:
 		logger.PrintInfo("Installed gitbm hook")
-
-		logger.PrintSuccess("Gitbm initialized successfully. Ready to use! 🚀")
-
-	},
+	case gitbm.TrackingWatch:
+		if pid, running := app.runningWatchPid(); running {
+			logger.PrintInfo("gitbm watch is already running (pid %d)", pid)
+			return nil
+		}
+		pid, err := app.startWatchDaemon()
+		if err != nil {
+			return err
+		}
+		logger.PrintInfo("Started gitbm watch in the background (pid %d)", pid)
+		logger.PrintWarning("Start it again with 'gitbm watch --daemon' after a reboot, eg. from your shell profile")
+	case gitbm.TrackingNone:
+		logger.PrintWarning("Checkouts are not tracked, 'recent', 'frequent', 'report' and back/forward will stay empty")
+	}
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVar(&importReflogFlag, "import-reflog", false, "Import the checkout history from the git reflog")
+	initCmd.Flags().StringVar(&trackingFlag, "tracking", gitbm.TrackingHook, "How checkouts are tracked: hook, watch or none")
 }
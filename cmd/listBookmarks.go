@@ -1,14 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +16,7 @@ var listBookmarksCmd = &cobra.Command{
 List all bookmark groups in the current Git repository.
 
 This command displays all the bookmark groups that have been created
-in the current repository. If no bookmark groups exist, it will suggest
+in the current repository, along with the rules of smart bookmark groups. If no bookmark groups exist, it will suggest
 using the 'gitbm add' command to create one.
 
 Usage:
@@ -29,44 +26,32 @@ Example:
   gitbm list bookmarks
 
 Note: This command must be run from within a Git repository initialized with gitbm.`,
-	Run: func(cmd *cobra.Command, args []string) {
-
-		err := utils.ValidateBasic()
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
-		// Get db connection
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbFilePath)
-
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
-
-		defer db.Close()
+		defer client.Close()
 
 		// Now we can list the bookmarks
-		bookmarkGroupRepo := models.NewBookmarkGroupRepository(db)
-		// bg := &models.BookmarkGroup{}
-		bookmarksList, err := bookmarkGroupRepo.List()
+		bookmarksList, err := client.Groups()
 
 		if err != nil {
-			logger.PrintError("Error getting bookmark groups: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting bookmark groups: %w", err)
 		}
 		if len(bookmarksList) == 0 {
-			logger.PrintError("No bookmark groups found. Use `gitbm add` to add a bookmark group.")
-			os.Exit(1)
+			return errors.New("no bookmark groups found, use `gitbm add` to add a bookmark group")
 		}
 
 		logger.PrintSuccess("Found bookmarks:")
 		for _, bookmark := range bookmarksList {
+			if bookmark.IsSmart() {
+				logger.Print("%s (%s)", bookmark.Name, gitbm.DescribeRule(bookmark))
+				continue
+			}
 			logger.Print(bookmark.Name)
 		}
+		return nil
 	},
 }
 
@@ -1,17 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
-	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	"github.com/spf13/cobra"
 )
 
+var longListFlag bool
+
 var listBranchesCmd = &cobra.Command{
 	Use:   "branches",
 	Short: "List all branches under the current bookmark group",
@@ -22,61 +20,80 @@ This command fetches and displays the branches tied to the currently active book
 in the repository's database. If no branches are found, it will suggest adding them
 via 'gitbm branch add' command.
 
+Use --long (-l) to also show the notes attached to each branch.
+Use --tag (-t) to list the branches having all of the given labels, across every bookmark group.
+
 Usage:
-  gitbm list branches
+  gitbm list branches [--long] [--tag <label>]...
 
 Example:
   gitbm list branches
+  gitbm list branches --long
+  gitbm list branches --tag needs-review
 
 Note: This command must be run within a Git repository initialized with gitbm.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		err := utils.ValidateBasic()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-
-		// Get db connection
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbFilePath)
-
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			fmt.Println("Error getting db connection:", err)
-			return
-		}
-
-		defer db.Close()
-
-		// Get current bookmark group id
-		currentBookmarkGrpRepo := models.NewCurrentBookmarkGroupRepository(db)
-		currentBookmarkGroupId, err := currentBookmarkGrpRepo.GetCurrentBookmarkGroupId()
-
-		if err != nil {
-			fmt.Println("Error getting current bookmark group id:", err)
-			return
+			return err
 		}
+		defer client.Close()
 
 		// Get the branches
-		branchRepo := models.NewBranchRepository(db)
-		branch := &models.Branch{BookmarkGroupID: currentBookmarkGroupId}
-		branches, err := branchRepo.ListByBookmarkGroupId(branch.BookmarkGroupID)
+		branches, _, err := listBranchesToPick(client)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
 		if len(branches) == 0 {
-			logger.PrintError("No branches found. Use `gitbm add` to add a branch.")
-			os.Exit(1)
+			if len(tagFilterFlag) > 0 {
+				return errors.New("no branches with the given labels")
+			}
+			return errors.New("no branches found, use `gitbm add` to add a branch")
+		}
+
+		// Show the bookmark group of each branch when listing across groups
+		var groupNames map[int64]string
+		if len(tagFilterFlag) > 0 {
+			groupNames, err = client.GroupNames()
+			if err != nil {
+				return err
+			}
 		}
 
 		for _, b := range branches {
-			fmt.Printf("- Branch: %s, Alias: %s\n", b.Name, b.Alias)
+			line := fmt.Sprintf("- %s: %s", bookmarkKindLabel(b), b.DisplayName())
+			if groupNames != nil {
+				line = fmt.Sprintf("- Group: %s, %s: %s", groupNames[b.BookmarkGroupID], bookmarkKindLabel(b), b.DisplayName())
+			}
+			// Branches of smart groups have no alias
+			if b.Alias != "" {
+				line += fmt.Sprintf(", Alias: %s", b.Alias)
+			}
+			if b.Remote != "" {
+				line += fmt.Sprintf(", Remote: %s", b.Remote)
+			}
+			if len(b.Tags) > 0 {
+				line += fmt.Sprintf(", Labels: %s", strings.Join(b.Tags, ", "))
+			}
+			if len(b.Tickets) > 0 {
+				line += fmt.Sprintf(", Tickets: %s", strings.Join(b.Tickets, ", "))
+			}
+			fmt.Println(line)
+
+			if longListFlag && b.Notes != "" {
+				for _, line := range strings.Split(b.Notes, "\n") {
+					fmt.Printf("    %s\n", line)
+				}
+			}
 		}
+		return nil
 	},
 }
 
 func init() {
+	listBranchesCmd.Flags().BoolVarP(&longListFlag, "long", "l", false, "Also show the notes of each branch")
+	listBranchesCmd.Flags().StringSliceVarP(&tagFilterFlag, "tag", "t", nil, "Only list branches with these labels, across all bookmark groups")
+	listBranchesCmd.RegisterFlagCompletionFunc("tag", completeTags)
 	listCmd.AddCommand(listBranchesCmd)
 }
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/internal/utils"
+	templateutils "github.com/devadathanmb/gitbm/internal/utils/templateUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+// Name of the template used when --template is not given
+const defaultTemplateName = "default"
+
+var newTemplateFlag string
+var newSetFlag map[string]string
+var newBaseFlag string
+var newCheckoutFlag bool
+
+var newCmd = &cobra.Command{
+	Use:   "new [title]",
+	Short: "Create a branch from a naming template and bookmark it",
+	Long: `
+Create a new branch named after a template and bookmark it in the current bookmark group,
+with the human readable title as its alias.
+
+Templates are read from the gitbm.template.<name> git config, and contain variables
+such as {{type}} or {{ticket}}. You are prompted for the value of each variable, unless
+it is given with --set. Two variables are special:
+- {{title}} is the title of the branch
+- {{slug}} is the title turned into a branch name fragment (eg. fix-login-page),
+  or a random name when no title is given
+
+Variables left empty are dropped from the name along with their separators.
+Without any configured template, the default template is {{type}}/{{ticket}}-{{slug}}.
+
+The branch is created from --base (default HEAD), and checked out with --checkout.
+
+Usage:
+  gitbm new [title] [--template <name>] [--set <var>=<value>]... [--base <ref>] [--checkout]
+
+Examples:
+  git config gitbm.template.default '{{type}}/{{ticket}}-{{slug}}'
+  git config gitbm.template.hotfix 'hotfix/{{ticket}}'
+
+  gitbm new "Fix the login page"                  # Prompts for type and ticket
+  gitbm new "Fix the login page" --set type=fix --set ticket=JIRA-123 --base main
+  gitbm new --template hotfix --set ticket=JIRA-456 --checkout
+
+Note: This command must be run from within a Git repository initialized with gitbm,
+and a bookmark group must be active.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		template, err := getBranchTemplate(newTemplateFlag)
+		if err != nil {
+			return err
+		}
+
+		if !app.Repo().RefExists(newBaseFlag) {
+			return fmt.Errorf("base '%s' does not exist", newBaseFlag)
+		}
+
+		bookmarkGroup, err := getExplicitCurrentGroup(client)
+		if err != nil {
+			return err
+		}
+
+		// Fill in the variables of the template
+		reader := bufio.NewReader(os.Stdin)
+		values := make(map[string]string, len(newSetFlag))
+		for name, value := range newSetFlag {
+			values[name] = value
+		}
+
+		title := values[templateutils.VarTitle]
+		if len(args) > 0 {
+			title = args[0]
+		} else if title == "" {
+			title = prompt(reader, "Title (leave empty for a random name): ")
+		}
+		values[templateutils.VarTitle] = title
+
+		if _, ok := values[templateutils.VarSlug]; !ok {
+			values[templateutils.VarSlug] = templateutils.Slugify(title)
+			if values[templateutils.VarSlug] == "" {
+				values[templateutils.VarSlug] = utils.GetRandomName()
+			}
+		}
+
+		for _, variable := range templateutils.Variables(template) {
+			if _, ok := values[variable]; !ok {
+				values[variable] = prompt(reader, variable+": ")
+			}
+		}
+
+		branchName := templateutils.Render(template, values)
+		if err := app.Repo().CheckBranchName(branchName); err != nil {
+			return err
+		}
+
+		if app.Repo().RefExists("refs/heads/" + branchName) {
+			return fmt.Errorf("branch %s already exists, use `gitbm add --branch %s` to bookmark it", branchName, branchName)
+		}
+
+		err = app.Repo().CreateBranch(branchName, newBaseFlag)
+		if err != nil {
+			return err
+		}
+
+		logger.PrintInfo("Created branch %s from %s", branchName, newBaseFlag)
+
+		alias := title
+		if alias == "" {
+			alias = branchName
+		}
+
+		err = client.AddBookmark(bookmarkGroup.Name, &gitbm.Bookmark{
+			Name:  branchName,
+			Alias: alias,
+		})
+		if err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Branch %s added successfully", branchName)
+
+		if !newCheckoutFlag {
+			return nil
+		}
+
+		err = client.CheckoutBranch(branchName)
+		if err != nil {
+			return err
+		}
+
+		logger.PrintInfo("Checked out to branch: %s", branchName)
+		return nil
+	},
+}
+
+// Gets a branch naming template from the gitbm.template.<name> git config.
+// The default template does not need to be configured.
+func getBranchTemplate(name string) (string, error) {
+	// Git config keys are case-insensitive
+	name = strings.ToLower(name)
+	templates := app.Repo().ListGitConfig("gitbm.template.")
+	if template, ok := templates[name]; ok {
+		return template, nil
+	}
+
+	if name == defaultTemplateName {
+		return templateutils.DefaultTemplate, nil
+	}
+
+	if len(templates) == 0 {
+		return "", fmt.Errorf("template '%s' not found, add it with 'git config gitbm.template.%s <template>'", name, name)
+	}
+	return "", fmt.Errorf("template '%s' not found, use one of: %s", name, strings.Join(listTemplateNames(templates), ", "))
+}
+
+func listTemplateNames(templates map[string]string) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Prompts for a single line of input
+func prompt(reader *bufio.Reader, message string) string {
+	fmt.Print(message)
+	response, _ := reader.ReadString('\n')
+	return strings.TrimSpace(response)
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd)
+	newCmd.Flags().StringVar(&newTemplateFlag, "template", defaultTemplateName, "Name of the gitbm.template.<name> git config to use")
+	newCmd.Flags().StringToStringVarP(&newSetFlag, "set", "s", nil, "Value of a template variable, eg. --set ticket=JIRA-123")
+	newCmd.Flags().StringVar(&newBaseFlag, "base", "HEAD", "Starting point of the new branch")
+	newCmd.Flags().BoolVarP(&newCheckoutFlag, "checkout", "c", false, "Checkout the new branch")
+	newCmd.RegisterFlagCompletionFunc("template", completeTemplates)
+	newCmd.RegisterFlagCompletionFunc("base", completeGitBranches)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/internal/utils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var editNoteFlag bool
+var searchNotesFlag string
+
+var noteCmd = &cobra.Command{
+	Use:   "note [branch-name]",
+	Short: "Show, edit or search the notes of bookmarked branches",
+	Long: `
+Show or edit the free-text notes attached to a bookmarked branch.
+
+Notes can hold anything worth remembering about a branch, such as a ticket URL,
+a PR URL, "waiting on review from X" or reproduction steps.
+
+If no branch name is provided, the current Git branch is used. The branch must be
+bookmarked in the current bookmark group.
+
+Use --edit (-e) to open the notes in $VISUAL or $EDITOR (falls back to vi).
+Use --search (-s) to search the notes of bookmarks in every bookmark group.
+All words of the search query must appear in the notes.
+
+Usage:
+  gitbm note [branch-name] [--edit]
+  gitbm note --search <query>
+
+Examples:
+  gitbm note                       # Show the notes of the current branch
+  gitbm note feature/1234          # Show the notes of a specific branch
+  gitbm note feature/1234 --edit   # Edit the notes of a specific branch
+  gitbm note --search "review"     # Find bookmarks whose notes mention review
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("search") && len(args) > 0 {
+			return errors.New("--search searches the notes of every bookmark, it takes no branch name")
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: completeBranches,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if cmd.Flags().Changed("search") {
+			return searchNotes(client, searchNotesFlag)
+		}
+
+		var branchName string
+		if len(args) > 0 {
+			branchName = args[0]
+		} else {
+			branchName, err = app.Repo().GetCurrentGitBranch()
+			if err != nil {
+				return fmt.Errorf("error getting current branch name: %w", err)
+			}
+		}
+
+		branch, err := client.Bookmark("", branchName)
+		if errors.Is(err, gitbm.ErrNoCurrentGroup) {
+			logger.PrintInfo("No bookmark group set.")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !editNoteFlag {
+			if branch.Notes == "" {
+				logger.PrintInfo("No notes for branch %s. Use `gitbm note %s --edit` to add some.", branch.Name, branch.Name)
+				return nil
+			}
+			logger.PrintSuccess("Notes for branch %s:", branch.Name)
+			logger.Print("%s", branch.Notes)
+			return nil
+		}
+
+		notes, err := utils.EditText(branch.Notes)
+		if err != nil {
+			return err
+		}
+
+		if notes == branch.Notes {
+			logger.PrintInfo("Notes unchanged")
+			return nil
+		}
+
+		err = client.SetNotes(*branch, notes)
+		if err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Notes for branch %s updated", branch.Name)
+		return nil
+	},
+}
+
+// Prints the bookmarks whose notes match the search query
+func searchNotes(client *gitbm.Client, query string) error {
+	branches, err := client.SearchNotes(query)
+	if err != nil {
+		return err
+	}
+
+	if len(branches) == 0 {
+		logger.PrintInfo("No notes matching '%s'", query)
+		return nil
+	}
+
+	groupNames, err := client.GroupNames()
+	if err != nil {
+		return fmt.Errorf("error getting bookmark groups: %w", err)
+	}
+
+	logger.PrintSuccess("Found notes:")
+	for _, b := range branches {
+		logger.PrintInfo("%s / %s", groupNames[b.BookmarkGroupID], formatBranch(b))
+		logger.Print("%s\n", b.Notes)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(noteCmd)
+	noteCmd.Flags().BoolVarP(&editNoteFlag, "edit", "e", false, "Edit the notes in $EDITOR")
+	noteCmd.Flags().StringVarP(&searchNotesFlag, "search", "s", "", "Search the notes of bookmarks in all bookmark groups")
+	noteCmd.MarkFlagsMutuallyExclusive("edit", "search")
+}
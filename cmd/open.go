@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var openPRFlag bool
+var openPrintFlag bool
+
+var openCmd = &cobra.Command{
+	Use:   "open [branch-name]",
+	Short: "Open the ticket or pull request of a branch in the browser",
+	Long: `
+Open the issue tracker ticket, or the pull request, of a branch in the browser.
+
+Ticket keys are extracted from the branch name with the regexes of the configured trackers,
+and turned into URLs with their URL templates. A tracker is configured in git config with:
+- gitbm.tracker.<name>.pattern: regex matching the ticket keys, the first capture group
+  is the key if there is one (default: keys such as JIRA-123)
+- gitbm.tracker.<name>.url: URL of a ticket, where {{ticket}} is the key and {{branch}}
+  the branch name
+
+Use --pr to open the pull request instead, from the gitbm.prUrl URL template ({{branch}}
+is the branch name). URLs are built from the templates only, nothing is looked up online.
+
+URLs are opened with $BROWSER, falling back to xdg-open. Use --print (-p) to only print them.
+
+Without any configured tracker, keys such as JIRA-123 are still shown in the pickers and in
+'gitbm list branches', they just have no URL. It fails when there is no URL to open, eg.
+no ticket in the branch name or no URL template for its tickets.
+
+If no branch name is provided, the current Git branch is used.
+
+Usage:
+  gitbm open [branch-name] [--pr] [--print]
+
+Examples:
+  git config gitbm.tracker.jira.pattern 'JIRA-[0-9]+'
+  git config gitbm.tracker.jira.url 'https://acme.atlassian.net/browse/{{ticket}}'
+  git config gitbm.tracker.clickup.pattern 'CLICKUP-([0-9a-z]+)'
+  git config gitbm.tracker.clickup.url 'https://app.clickup.com/t/{{ticket}}'
+  git config gitbm.prUrl 'https://github.com/acme/app/compare/{{branch}}'
+
+  gitbm open                        # Open the ticket of the current branch
+  gitbm open feature/JIRA-123-login --print
+  gitbm open --pr
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeGitBranches,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var branchName string
+		if len(args) > 0 {
+			branchName = args[0]
+		} else {
+			branchName, err = app.Repo().GetCurrentGitBranch()
+			if err != nil {
+				return fmt.Errorf("error getting current branch name: %w", err)
+			}
+		}
+
+		var urls []string
+		if openPRFlag {
+			url, err := client.PullRequestURL(branchName)
+			if err != nil {
+				return err
+			}
+			urls = append(urls, url)
+		} else {
+			tickets, err := client.Tickets(branchName)
+			if err != nil {
+				return err
+			}
+
+			if len(tickets) == 0 {
+				return fmt.Errorf("no ticket found in branch %s", branchName)
+			}
+
+			for _, ticket := range tickets {
+				if ticket.URL == "" {
+					logger.PrintWarning("Ticket %s has no URL, set it with 'git config gitbm.tracker.%s.url <url>'", ticket.Key, ticket.Tracker)
+					continue
+				}
+				urls = append(urls, ticket.URL)
+			}
+			if len(urls) == 0 {
+				return fmt.Errorf("no URL for the tickets of branch %s", branchName)
+			}
+		}
+
+		for _, url := range urls {
+			if openPrintFlag {
+				fmt.Println(url)
+				continue
+			}
+
+			if err := utils.OpenURL(url); err != nil {
+				return err
+			}
+			logger.PrintInfo("Opened %s", url)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	openCmd.Flags().BoolVar(&openPRFlag, "pr", false, "Open the pull request of the branch instead of its ticket")
+	openCmd.Flags().BoolVarP(&openPrintFlag, "print", "p", false, "Only print the URLs")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var oplogLimitFlag int
+
+var oplogCmd = &cobra.Command{
+	Use:   "oplog [--limit <n>]",
+	Short: "Show the undo log",
+	Long: `
+Show the changes recorded in the undo log, newest first, with their state:
+- done:    applied, 'gitbm undo' undoes the most recent one, marked with '*'
+- undone:  undone, 'gitbm redo' applies the most recently undone one again
+- skipped: left out by 'gitbm undo --skip', as it could no longer be undone
+
+If the change undone next conflicts with a change made outside of the undo log, eg. by
+an older version of gitbm, the conflict is shown below the log.
+
+Usage:
+  gitbm oplog [--limit <n>]
+
+Examples:
+  gitbm oplog
+  gitbm oplog --limit 0
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		// sqlite does not limit the rows with a negative limit
+		limit := oplogLimitFlag
+		if limit <= 0 {
+			limit = -1
+		}
+		operations, err := client.Operations(limit)
+		if err != nil {
+			return err
+		}
+		if len(operations) == 0 {
+			logger.PrintInfo("No changes recorded yet")
+			return nil
+		}
+
+		next, nextErr := client.NextUndo()
+		var conflict *gitbm.ConflictError
+		if nextErr != nil && !errors.As(nextErr, &conflict) {
+			return nextErr
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  ID\tSTATE\tDATE\tCOMMAND\tCHANGE")
+		for _, op := range operations {
+			marker := " "
+			if next != nil && op.ID == next.ID {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s %d\t%s\t%s\t%s\t%s\n", marker, op.ID, op.State, op.CreatedAt.Local().Format("2006-01-02 15:04"), op.Command, op.Description)
+		}
+		w.Flush()
+
+		if conflict != nil {
+			logger.PrintWarning("%v", conflict)
+			logger.Print("  - Run 'gitbm undo --skip' to leave it as is and undo the changes before it")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(oplogCmd)
+	oplogCmd.Flags().IntVar(&oplogLimitFlag, "limit", 20, "Number of changes to show, 0 for all")
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var pruneDryRunFlag bool
+var pruneYesFlag bool
+var pruneInactiveFlag int
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove bookmarks and checkout history of stale branches",
+	Long: `
+Find stale branches and remove their bookmarks and checkout stats.
+
+A branch is stale when:
+- It no longer exists, neither locally nor on any remote
+- It is fully merged into the default branch
+- It was not checked out for --inactive days (optional)
+
+The default branch is taken from the gitbm.defaultBranch git config, origin/HEAD,
+or main/master. Branches merged into the default branch are only detected when
+they still exist locally, and a branch pointing at the tip of the default branch
+is not considered merged.
+
+The stale branches are listed and, after confirmation, removed in one go.
+Use --dry-run to only list them.
+
+To prune inactive branches automatically on every checkout, set the gitbm.pruneAfterDays
+git config. Automatic pruning only looks at inactivity, never at git.
+
+Usage:
+  gitbm prune [--dry-run] [--yes] [--inactive <days>]
+
+Examples:
+  gitbm prune --dry-run
+  gitbm prune --yes
+  gitbm prune --inactive 90
+  git config gitbm.pruneAfterDays 90      # Prune inactive branches automatically
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		inactiveDays := pruneInactiveFlag
+		if !cmd.Flags().Changed("inactive") {
+			days, err := getPruneAfterDays()
+			if err != nil {
+				logger.PrintWarning("Ignoring %v", err)
+			}
+			inactiveDays = days
+		}
+
+		candidates, err := client.FindStale(gitbm.PruneOptions{
+			CheckGit:      true,
+			InactiveAfter: time.Duration(inactiveDays) * 24 * time.Hour,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(candidates) == 0 {
+			logger.PrintInfo("Nothing to prune.")
+			return nil
+		}
+
+		groupNames, err := client.GroupNames()
+		if err != nil {
+			return fmt.Errorf("error getting bookmark groups: %w", err)
+		}
+
+		logger.PrintWarning("Found %d stale branches:", len(candidates))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BRANCH\tREASON\tBOOKMARKED IN")
+		for _, c := range candidates {
+			var groups []string
+			for _, b := range c.Bookmarks {
+				groups = append(groups, groupNames[b.BookmarkGroupID])
+			}
+			bookmarkedIn := strings.Join(groups, ", ")
+			if bookmarkedIn == "" {
+				bookmarkedIn = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Reason, bookmarkedIn)
+		}
+		w.Flush()
+
+		if pruneDryRunFlag {
+			return nil
+		}
+
+		if !pruneYesFlag {
+			fmt.Print("Remove their bookmarks and checkout history? (Y/N): ")
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+
+			if response != "y" && response != "yes" {
+				fmt.Println("Operation cancelled.")
+				return nil
+			}
+		}
+
+		if err := client.Prune(candidates); err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Pruned %d stale branches", len(candidates))
+		return nil
+	},
+}
+
+// Gets the number of inactive days after which branches are pruned from the
+// gitbm.pruneAfterDays git config, 0 when automatic pruning is disabled.
+// An invalid value disables it too, along with an error.
+func getPruneAfterDays() (int, error) {
+	value, ok := app.Repo().GetGitConfig("gitbm.pruneAfterDays")
+	if !ok {
+		return 0, nil
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid gitbm.pruneAfterDays '%s'", value)
+	}
+	return days, nil
+}
+
+// Prunes the branches inactive for gitbm.pruneAfterDays after a checkout is tracked, if enabled.
+// It prints nothing, as the hook runs it on every checkout: failures and an invalid config are
+// returned to the caller.
+func autoPrune(client *gitbm.Client) error {
+	days, err := getPruneAfterDays()
+	if err != nil || days == 0 {
+		return err
+	}
+
+	candidates, err := client.FindStale(gitbm.PruneOptions{InactiveAfter: time.Duration(days) * 24 * time.Hour})
+	if err == nil && len(candidates) > 0 {
+		err = client.Prune(candidates)
+	}
+	if err != nil {
+		return fmt.Errorf("error pruning inactive branches: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().BoolVarP(&pruneDryRunFlag, "dry-run", "n", false, "Only list the stale branches")
+	pruneCmd.Flags().BoolVarP(&pruneYesFlag, "yes", "y", false, "Prune without confirmation")
+	pruneCmd.Flags().IntVar(&pruneInactiveFlag, "inactive", 0, "Also prune branches not checked out for this many days (default gitbm.pruneAfterDays)")
+	pruneCmd.MarkFlagsMutuallyExclusive("dry-run", "yes")
+}
@@ -2,15 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
-	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
-	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -39,25 +33,12 @@ or frequently use.`,
   gitbm recent --reverse
   # List and select from the 10 least frequently used branches
   gitbm recent frequent --reverse`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Validate basic
-		err := utils.ValidateBasic()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
-
-		// Get db
-		currentDir, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbPath)
-
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
-		defer db.Close()
+		defer client.Close()
 
 		var limit int
 		var isReverse bool
@@ -65,8 +46,7 @@ or frequently use.`,
 		if cmd.Flags().Changed("limit") {
 			limit, err = cmd.Flags().GetInt("limit")
 			if err != nil {
-				logger.PrintError(fmt.Sprint(err))
-				os.Exit(1)
+				return err
 			}
 		} else {
 			limit = 10
@@ -80,20 +60,19 @@ or frequently use.`,
 
 		// Get the branch names and fzf them
 
-		branchCheckoutRepo := models.NewBranchCheckoutRepository(db)
-		var branches []models.BranchCheckout
+		var branches []gitbm.BranchStats
 		if len(args) > 0 && args[0] == "frequent" {
-			branches, err = branchCheckoutRepo.GetRecentFrequent(limit, isReverse)
+			branches, err = client.RecentFrequentBranches(limit, isReverse)
 		} else {
-			branches, err = branchCheckoutRepo.GetRecent(limit, isReverse)
+			branches, err = client.RecentBranches(limit, isReverse)
 		}
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
 		selectedBranch, err := fzfutils.FuzzyFind(
+			app.Picker,
 			branches,
-			func(b models.BranchCheckout) string {
+			func(b gitbm.BranchStats) string {
 				if b.LatestCommitMsg != "" {
 					return fmt.Sprintf("%s -- %s", b.Name, b.LatestCommitMsg)
 				}
@@ -102,19 +81,14 @@ or frequently use.`,
 			"Select a branch to checkout to",
 		)
 		if err != nil {
-			if err == fzfutils.ErrSelectionCancelled {
-				logger.PrintInfo("Branch selection cancelled")
-				os.Exit(0)
-			}
-			logger.PrintError("Error selecting branch: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("error selecting branch: %w", err)
 		}
 
-		err = gitutils.GitCheckout(selectedBranch.Name)
+		err = client.CheckoutBranch(selectedBranch.Name)
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
+		return nil
 	},
 }
 
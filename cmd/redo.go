@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Apply the last undone change again",
+	Long: `
+Apply again the change most recently undone by 'gitbm undo'.
+
+Undone changes can only be redone until something else changes the bookmarks, the
+bookmark groups or the checkout stats, see 'gitbm oplog'.
+
+Usage:
+  gitbm redo
+
+Example:
+  gitbm redo
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		op, err := client.Redo()
+		if errors.Is(err, gitbm.ErrNothingToRedo) {
+			logger.PrintInfo("Nothing to redo")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Redid #%d: %s", op.ID, op.Description)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(redoCmd)
+}
@@ -1,16 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
-	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -30,90 +26,75 @@ Examples:
   gitbm remove
   # Remove the current branch
   gitbm remove -b current
+  # Interactively select a labelled branch to remove, across all bookmark groups
+  gitbm remove --tag blocked
 Note: This command must be run from within a Git repository initialized with gitbm.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := utils.ValidateBasic(); err != nil {
-			logger.PrintError("%v", err)
-			os.Exit(1)
-		}
-
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbFilePath)
-		if err != nil {
-			logger.PrintError("Error getting db connection: %v", err)
-			os.Exit(1)
-		}
-		defer db.Close()
-
-		currentBookmarkGrpRepo := models.NewCurrentBookmarkGroupRepository(db)
-		currentBookmarkGroupId, err := currentBookmarkGrpRepo.GetCurrentBookmarkGroupId()
+	ValidArgsFunction: completeBranches,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError("Error getting current bookmark group id: %v", err)
-			os.Exit(1)
+			return err
 		}
+		defer client.Close()
 
-		branchRepo := models.NewBranchRepository(db)
-		var branchName string
+		var branch *gitbm.Bookmark
 
-		if cmd.Flags().Changed("branch") {
-			if branchNameFlag == "current" {
-				branchName, err = gitutils.GetCurrentGitBranch()
+		if cmd.Flags().Changed("branch") || len(args) > 0 {
+			var branchName string
+			if len(args) > 0 && !cmd.Flags().Changed("branch") {
+				branchName = args[0]
+			} else if branchNameFlag == "current" {
+				branchName, err = app.Repo().GetCurrentGitBranch()
 				if err != nil {
-					logger.PrintError("Error getting current branch name: %v", err)
-					os.Exit(1)
+					return fmt.Errorf("error getting current branch name: %w", err)
 				}
 				logger.PrintInfo("Using current branch: %s", branchName)
 			} else {
 				branchName = branchNameFlag
 			}
-		} else if len(args) > 0 {
-			branchName = args[0]
+
+			branch, err = client.Bookmark("", branchName)
+			if err != nil {
+				return fmt.Errorf("error getting branch: %w", err)
+			}
 		} else {
 			// No branch specified, use fzf to select
-			branches, err := branchRepo.ListByBookmarkGroupId(currentBookmarkGroupId)
+			branches, displayFunc, err := listBranchesToPick(client)
 			if err != nil {
-				logger.PrintError("Error getting branches: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error getting branches: %w", err)
 			}
 			if len(branches) == 0 {
-				logger.PrintError("No branches found. Use `gitbm add` to add a branch.")
-				os.Exit(1)
+				if len(tagFilterFlag) > 0 {
+					return errors.New("no branches with the given labels")
+				}
+				return errors.New("no branches found, use `gitbm add` to add a branch")
 			}
 
 			selectedBranch, err := fzfutils.FuzzyFind(
+				app.Picker,
 				branches,
-				func(b models.Branch) string {
-					if b.Alias != "" {
-						return fmt.Sprintf("%s -- %s", b.Name, b.Alias)
-					}
-					return b.Name
-				},
+				displayFunc,
 				"Select a branch to remove",
+				fzfutils.WithPreview(branches, formatBranchPreview),
 			)
 			if err != nil {
-				if err == fzfutils.ErrSelectionCancelled {
-					logger.PrintInfo("Branch selection cancelled")
-					os.Exit(0)
-				}
-				logger.PrintError("Error selecting branch: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error selecting branch: %w", err)
 			}
-			branchName = selectedBranch.Name
+			branch = &selectedBranch
 		}
 
-		branch, err := branchRepo.GetByName(currentBookmarkGroupId, branchName)
-		if err != nil {
-			logger.PrintError("Error getting branch: %v", err)
-			os.Exit(1)
+		// Branches of smart groups can not be removed, but labelled branches of other groups can
+		if err := client.RemoveBookmark(*branch); err != nil {
+			return fmt.Errorf("error removing branch: %w", err)
 		}
 
-		if err := branchRepo.Remove(branch.BookmarkGroupID, branch.Name); err != nil {
-			logger.PrintError("Error removing branch: %v", err)
-			os.Exit(1)
+		if len(tagFilterFlag) > 0 {
+			logger.PrintSuccess("Branch '%s' removed successfully from its bookmark group", branch.Name)
+			return nil
 		}
 
-		logger.PrintSuccess("Branch '%s' removed successfully from the current bookmark group", branchName)
+		logger.PrintSuccess("Branch '%s' removed successfully from the current bookmark group", branch.Name)
+		return nil
 	},
 }
 
@@ -121,4 +102,7 @@ func init() {
 	rootCmd.AddCommand(removeCmd)
 	removeCmd.Flags().StringVarP(&branchNameFlag, "branch", "b", "", "Remove the specified branch from the current bookmark group")
 	removeCmd.Flag("branch").NoOptDefVal = "current"
+	removeCmd.RegisterFlagCompletionFunc("branch", completeFlagValue(completeBranches))
+	removeCmd.Flags().StringSliceVarP(&tagFilterFlag, "tag", "t", nil, "Only show branches with these labels, across all bookmark groups")
+	removeCmd.RegisterFlagCompletionFunc("tag", completeTags)
 }
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	reportutils "github.com/devadathanmb/gitbm/internal/utils/reportUtils"
+	"github.com/spf13/cobra"
+)
+
+// Default cap on the time attributed to a single checkout, see --idle-cap
+const defaultIdleCap = 2 * time.Hour
+
+var reportSinceFlag string
+var reportUntilFlag string
+var reportGroupByFlag string
+var reportFormatFlag string
+var reportIdleCapFlag time.Duration
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report the time spent on branches",
+	Long: `
+Report how long you stayed on each branch, computed from the checkouts recorded by the hook.
+
+The time between a checkout and the next one is attributed to the checked out branch.
+Since the machine may have been idle in between, the time attributed to a single checkout
+is capped by --idle-cap, which defaults to the gitbm.idleCap git config or 2h.
+
+The report can be grouped by day, branch or bookmark group. Branches bookmarked in
+several bookmark groups are counted under each of them, and branches which are not
+bookmarked at all under '(none)'.
+
+--since and --until accept today, yesterday, a weekday (the most recent one, eg. monday),
+a date (2006-01-02), a number of days ago (eg. 7d) or a duration ago (eg. 36h).
+
+Usage:
+  gitbm report [--since <time>] [--until <time>] [--group-by day|branch|group] [--format table|csv|json]
+
+Examples:
+  gitbm report                                # This week, by branch
+  gitbm report --since monday --group-by day
+  gitbm report --since 2024-01-01 --until 2024-02-01 --group-by group --format csv
+  gitbm report --since 7d --idle-cap 1h --format json
+  git config gitbm.idleCap 90m                # Change the default idle cap
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		now := time.Now()
+		since, err := reportutils.ParseSince(reportSinceFlag, now)
+		if err != nil {
+			return err
+		}
+
+		until := now
+		if cmd.Flags().Changed("until") {
+			until, err = reportutils.ParseSince(reportUntilFlag, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !until.After(since) {
+			return errors.New("--until must be after --since")
+		}
+
+		idleCap := reportIdleCapFlag
+		if !cmd.Flags().Changed("idle-cap") {
+			idleCap = getIdleCap()
+		}
+
+		events, err := client.CheckoutEvents(since, until)
+		if err != nil {
+			return err
+		}
+
+		intervals := reportutils.ComputeIntervals(events, since, until, idleCap)
+
+		// Map branches to their bookmark groups
+		var branchGroups map[string][]string
+		if reportGroupByFlag == reportutils.GroupByGroup {
+			branches, groupNames, err := listAllBookmarks(client)
+			if err != nil {
+				return fmt.Errorf("error getting bookmarks: %w", err)
+			}
+			branchGroups = make(map[string][]string)
+			for _, b := range branches {
+				branchGroups[b.Name] = append(branchGroups[b.Name], groupNames[b.BookmarkGroupID])
+			}
+		}
+
+		rows, err := reportutils.Aggregate(intervals, reportGroupByFlag, branchGroups)
+		if err != nil {
+			return err
+		}
+
+		switch reportFormatFlag {
+		case "table":
+			printReportTable(rows, since, until)
+		case "csv":
+			err = printReportCSV(rows)
+		case "json":
+			err = printReportJSON(rows)
+		default:
+			return fmt.Errorf("unknown format '%s', use one of: table, csv, json", reportFormatFlag)
+		}
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+// Gets the idle cap from the gitbm.idleCap git config, falling back to the default
+func getIdleCap() time.Duration {
+	value, ok := app.Repo().GetGitConfig("gitbm.idleCap")
+	if !ok {
+		return defaultIdleCap
+	}
+
+	idleCap, err := time.ParseDuration(value)
+	if err != nil {
+		logger.PrintWarning("Ignoring invalid gitbm.idleCap '%s', using %s", value, defaultIdleCap)
+		return defaultIdleCap
+	}
+	return idleCap
+}
+
+func printReportTable(rows []reportutils.Row, since time.Time, until time.Time) {
+	if len(rows) == 0 {
+		logger.PrintInfo("No checkouts recorded between %s and %s.", since.Format("2006-01-02 15:04"), until.Format("2006-01-02 15:04"))
+		return
+	}
+
+	logger.PrintSuccess("Time spent from %s to %s:", since.Format("2006-01-02 15:04"), until.Format("2006-01-02 15:04"))
+
+	var total time.Duration
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tTIME\n", reportKeyHeader())
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\n", row.Key, reportutils.FormatDuration(row.Duration))
+		total += row.Duration
+	}
+	// Bookmark groups may share branches, so their total would count time twice
+	if reportGroupByFlag != reportutils.GroupByGroup {
+		fmt.Fprintf(w, "TOTAL\t%s\n", reportutils.FormatDuration(total))
+	}
+	w.Flush()
+}
+
+func printReportCSV(rows []reportutils.Row) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{reportGroupByFlag, "seconds", "duration"})
+	for _, row := range rows {
+		w.Write([]string{row.Key, fmt.Sprint(int64(row.Duration.Seconds())), reportutils.FormatDuration(row.Duration)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printReportJSON(rows []reportutils.Row) error {
+	type jsonRow struct {
+		Key      string `json:"key"`
+		Seconds  int64  `json:"seconds"`
+		Duration string `json:"duration"`
+	}
+
+	output := make([]jsonRow, 0, len(rows))
+	for _, row := range rows {
+		output = append(output, jsonRow{
+			Key:      row.Key,
+			Seconds:  int64(row.Duration.Seconds()),
+			Duration: reportutils.FormatDuration(row.Duration),
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"group_by": reportGroupByFlag,
+		"rows":     output,
+	})
+}
+
+func reportKeyHeader() string {
+	switch reportGroupByFlag {
+	case reportutils.GroupByDay:
+		return "DAY"
+	case reportutils.GroupByGroup:
+		return "BOOKMARK GROUP"
+	default:
+		return "BRANCH"
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVar(&reportSinceFlag, "since", "monday", "Start of the report period")
+	reportCmd.Flags().StringVar(&reportUntilFlag, "until", "", "End of the report period (default now)")
+	reportCmd.Flags().StringVarP(&reportGroupByFlag, "group-by", "g", reportutils.GroupByBranch, "Group the time spent by day, branch or group")
+	reportCmd.Flags().StringVarP(&reportFormatFlag, "format", "f", "table", "Output format: table, csv or json")
+	reportCmd.Flags().DurationVar(&reportIdleCapFlag, "idle-cap", defaultIdleCap, "Maximum time attributed to a single checkout (default gitbm.idleCap or 2h)")
+	reportCmd.RegisterFlagCompletionFunc("group-by", cobra.FixedCompletions([]string{reportutils.GroupByDay, reportutils.GroupByBranch, reportutils.GroupByGroup}, cobra.ShellCompDirectiveNoFileComp))
+	reportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"table", "csv", "json"}, cobra.ShellCompDirectiveNoFileComp))
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"os"
 
+	"github.com/devadathanmb/gitbm/internal/logger"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -15,16 +17,67 @@ gitbm is a command-line tool designed to help you bookmark, manage, and switch b
 
 For more detailed documentation on each command, use 'gitbm <command> --help'.`,
 
-	// Uncomment this if you have any persistent flags or configuration
-	// PersistentPreRun: func(cmd *cobra.Command, args []string) {
-	//   // You can add common setup or validation code here
-	// },
+	// Errors are printed by Execute, along with their remedies
+	SilenceErrors: true,
+
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The arguments are valid by now, the usage would only hide why the command failed
+		cmd.SilenceUsage = true
+
+		switch {
+		case quietFlag:
+			logger.SetLevel(logger.LevelError)
+		case verboseFlag:
+			logger.SetLevel(logger.LevelDebug)
+		}
+		return app.setup()
+	},
+}
+
+var verboseFlag bool
+var quietFlag bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Print debug messages and the output of failed git commands")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Only print errors")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
 }
 
-// Execute starts the root command
+// Execute starts the root command, exiting with status 1 if it fails
 func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
+	if err := rootCmd.Execute(); err != nil {
+		printError(err)
 		os.Exit(1)
 	}
 }
+
+// ExecuteWith runs the command given by args against a, eg. a repository, database and picker
+// set up by a test, and returns its error instead of printing it and exiting.
+// Flags start from their defaults on every call.
+func ExecuteWith(a *App, args []string) error {
+	previous := app
+	app = a
+	defer func() { app = previous }()
+
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(args)
+	defer rootCmd.SetArgs(nil)
+	return rootCmd.Execute()
+}
+
+// Resets the flags of a command and of its subcommands to their defaults
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.LocalNonPersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
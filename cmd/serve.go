@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/internal/server"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/spf13/cobra"
+)
+
+var serveSocketFlag string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local HTTP/JSON API for editor and tool integrations",
+	Long: `
+Serve a local HTTP API with JSON responses on a unix socket, so that editor extensions
+and other tools do not have to run gitbm and parse its colored output for each call.
+
+The socket is per repository, created in the .git directory by default, and only
+the current user can connect to it.
+
+Endpoints:
+  GET  /groups                   Bookmark groups, with the current one flagged
+  PUT  /groups/current           Switch the bookmark group: {"name": "..."}
+  GET  /bookmarks                Bookmarks of the current group, ?group=<name> or ?all=true
+  GET  /history/recent           Recently checked out branches, ?limit=N (default 10)
+  GET  /history/frequent         Frequently checked out branches, ?limit=N (default 10)
+  GET  /history/navigation       Back/forward navigation history, oldest first
+  POST /checkout                 Checkout a bookmark: {"branch": "...", "group": "..."}
+  POST /jump                     Go back or forward: {"steps": 1, "direction": "back"}
+  GET  /events                   Stream checkout events as newline-delimited JSON,
+                                 ?after=<event id> to replay missed events
+
+Errors are returned as {"error": "..."} with a 4xx or 5xx status, along with
+"remedies" suggesting how to fix known git failures, eg. a dirty working tree.
+
+Usage:
+  gitbm serve [--socket <path>]
+
+Examples:
+  gitbm serve
+  curl --unix-socket .git/gitbm.sock http://gitbm/bookmarks
+  curl --unix-socket .git/gitbm.sock -X POST -d '{"branch": "feature/1234"}' http://gitbm/checkout
+  curl --unix-socket .git/gitbm.sock -N http://gitbm/events
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		socketPath := serveSocketFlag
+		if socketPath == "" {
+			socketPath = filepath.Join(gitutils.GetGitDir(client.Dir()), "gitbm.sock")
+		}
+
+		listener, err := server.Listen(socketPath)
+		if err != nil {
+			return err
+		}
+
+		// Closing the listener removes the socket file and stops the server
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			listener.Close()
+		}()
+
+		logger.PrintSuccess("Listening on %s", socketPath)
+		err = server.New(client).Serve(listener)
+		if err != nil {
+			return fmt.Errorf("error serving: %w", err)
+		}
+
+		logger.PrintInfo("Server stopped")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveSocketFlag, "socket", "", "Path of the unix socket (default .git/gitbm.sock)")
+}
@@ -1,14 +1,10 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
+	"errors"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +17,7 @@ Show the currently active bookmark group in the repository.
 
 This command fetches and displays the name of the active bookmark group, allowing
 you to confirm which group you're working with. If no bookmark group is currently
-set, it suggests using 'gitbm destroy' to reset and start over.
+set, it suggests creating one, or checking the setup with 'gitbm doctor'.
 
 Usage:
   gitbm show
@@ -30,40 +26,25 @@ Example:
   gitbm show
 
 Note: This command must be run within a Git repository initialized with gitbm.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Validate basic
-		err := utils.ValidateBasic()
-
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
+			return err
 		}
+		defer client.Close()
 
-		// Get db connection
-		currentDir, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbPath)
-
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
+		// Get current bookmark group
+		bookmarkGrp, err := client.CurrentGroup()
+		if errors.Is(err, gitbm.ErrNoCurrentGroup) {
+			logger.PrintInfo("No bookmark group set. Create one with `gitbm create <name>`, or run `gitbm doctor` if you had one.")
+			return nil
 		}
-
-		defer db.Close()
-
-		// Get current bookmark group id
-		bookmarkGroupRepo := models.NewBookmarkGroupRepository(db)
-		bookmarkGrp, err := bookmarkGroupRepo.GetCurrent()
-
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-		}
-
-		if bookmarkGrp == nil {
-			logger.PrintInfo("No bookmark group set. Better `gitbm destory` and start over.")
-			return
+			return err
 		}
 
 		logger.PrintSuccess("Current bookmark group: %s*", bookmarkGrp.Name)
-
+		return nil
 	},
 }
 
@@ -1,14 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
 	fzfutils "github.com/devadathanmb/gitbm/internal/utils/fzfUtils"
 	"github.com/spf13/cobra"
 )
@@ -35,38 +31,25 @@ Examples:
   gitbm switch
 
 Note: This command must be run from within a Git repository initialized with gitbm.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		err := utils.ValidateBasic()
-
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-		}
-
-		currentDir, _ := os.Getwd()
-		dbFilePath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbFilePath)
-
+	ValidArgsFunction: completeBookmarkGroups,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
-
-		defer db.Close()
+		defer client.Close()
 
 		var bookmarkGroupName string
-		bookmarkGroupRepo := models.NewBookmarkGroupRepository(db)
 		if len(args) > 0 {
 			bookmarkGroupName = args[0]
 		} else {
-			bookmarkGroupsList, err := bookmarkGroupRepo.List()
+			bookmarkGroupsList, err := client.Groups()
 
 			if err != nil {
-				logger.PrintError("Error getting bookmark groups: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error getting bookmark groups: %w", err)
 			}
 			if len(bookmarkGroupsList) == 0 {
-				logger.PrintInfo("No bookmark groups found. Use `gitbm add` to add a bookmark group.")
-				os.Exit(1)
+				return errors.New("no bookmark groups found, use `gitbm add` to add a bookmark group")
 			}
 
 			// Show fzf menu
@@ -75,38 +58,25 @@ Note: This command must be run from within a Git repository initialized with git
 				bookmarkGroupNames = append(bookmarkGroupNames, bookmark.Name)
 			}
 
-			selected, err := fzfutils.FuzzyFind(bookmarkGroupNames, func(bg string) string { return bg }, "Select bookmark group")
+			selected, err := fzfutils.FuzzyFind(app.Picker, bookmarkGroupNames, func(bg string) string { return bg }, "Select bookmark group")
 
 			if err != nil {
-				if err == fzfutils.ErrSelectionCancelled {
-					logger.PrintInfo("Selection cancelled")
-					os.Exit(1)
-				}
-				logger.PrintError("Error in fuzzy selection: %v", err)
-				os.Exit(1)
+				return fmt.Errorf("error in fuzzy selection: %w", err)
 			}
 
 			bookmarkGroupName = selected
 			logger.PrintInfo("Selected bookmark group: %s", bookmarkGroupName)
 
 		}
-		// Get bookmark group id
-		bookmarkGroup, err := bookmarkGroupRepo.GetByName(bookmarkGroupName)
-		if err != nil {
-			logger.PrintError("Error getting bookmark group: %v", err)
-			os.Exit(1)
-		}
 
 		// set current bookmark group
-		currentBookmarkGrpRepo := models.NewCurrentBookmarkGroupRepository(db)
-		err = currentBookmarkGrpRepo.SetCurrentBookmarkGroupId(bookmarkGroup.ID)
-
+		_, err = client.SwitchGroup(bookmarkGroupName)
 		if err != nil {
-			logger.PrintError("Error setting current bookmark group: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("error switching bookmark group: %w", err)
 		}
 
 		logger.PrintSuccess("Bookmark group switched to: %s*", bookmarkGroupName)
+		return nil
 	},
 }
 
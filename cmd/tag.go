@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+// Variable to hold the value of the --tag filter flag of checkout, list branches and remove
+var tagFilterFlag []string
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [branch-name] [+label|-label]...",
+	Short: "Add or remove labels on a bookmarked branch",
+	Long: `
+Manage the labels (tags) of a bookmarked branch in the current bookmark group.
+
+Labels are shared across bookmark groups, so they can be used to slice bookmarks
+across groups, eg. 'needs-review', 'blocked' or 'customer-acme'.
+
+Prefix a label with + to add it and with - to remove it. A label without a prefix is added.
+Labels are added before others are removed, and 'gitbm undo' reverts them all at once.
+If no branch name is provided, the current Git branch is used.
+If no labels are provided, the labels of the branch are shown.
+
+Use the --tag (-t) flag of 'checkout', 'list branches' and 'remove' to filter by labels.
+
+Usage:
+  gitbm tag [branch-name] [+label|-label]...
+
+Examples:
+  gitbm tag                                # Show the labels of the current branch
+  gitbm tag +needs-review                  # Label the current branch
+  gitbm tag feature/1234 +blocked -needs-review
+  gitbm checkout --tag blocked             # Pick from blocked branches of every group
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	// Flag parsing is disabled, as labels to be removed look like shorthand flags
+	DisableFlagParsing: true,
+	ValidArgsFunction:  completeBranches,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, arg := range args {
+			if arg == "-h" || arg == "--help" {
+				cmd.Help()
+				return nil
+			}
+		}
+
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var branchName string
+		if len(args) > 0 && !isTagArg(args[0]) {
+			branchName = args[0]
+			args = args[1:]
+		} else {
+			branchName, err = app.Repo().GetCurrentGitBranch()
+			if err != nil {
+				return fmt.Errorf("error getting current branch name: %w", err)
+			}
+		}
+
+		branch, err := client.Bookmark("", branchName)
+		if errors.Is(err, gitbm.ErrNoCurrentGroup) {
+			logger.PrintInfo("No bookmark group set.")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var add, remove []string
+		for _, arg := range args {
+			label := strings.TrimLeft(arg, "+-")
+			if label == "" || strings.ContainsAny(label, " \t\n") {
+				return fmt.Errorf("invalid label '%s'", arg)
+			}
+
+			if strings.HasPrefix(arg, "-") {
+				remove = append(remove, label)
+			} else {
+				add = append(add, label)
+			}
+		}
+		if len(add) > 0 || len(remove) > 0 {
+			if err := client.UpdateTags(branch, add, remove); err != nil {
+				return err
+			}
+		}
+
+		if len(branch.Tags) == 0 {
+			logger.PrintInfo("Branch %s has no labels", branch.Name)
+			return nil
+		}
+
+		logger.PrintSuccess("Labels of branch %s: %s", branch.Name, strings.Join(branch.Tags, ", "))
+		return nil
+	},
+}
+
+// Checks if an argument of the tag command is a label operation rather than a branch name
+func isTagArg(arg string) bool {
+	return strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-")
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+}
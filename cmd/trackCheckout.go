@@ -1,67 +1,81 @@
 package cmd
 
 import (
-	"fmt"
+	"errors"
 	"os"
+	"path/filepath"
 
-	"github.com/devadathanmb/gitbm/internal/db"
-	"github.com/devadathanmb/gitbm/internal/db/models"
 	"github.com/devadathanmb/gitbm/internal/logger"
-	"github.com/devadathanmb/gitbm/internal/utils"
-	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
 	"github.com/spf13/cobra"
 )
 
 // Non-user facing command to track the checkouts of a branch
-// This command should track two things:
+// This command should track four things:
 // 1. Increment the number of times the branch was checked out
 // 2. Update the last_checked_out_at timestamp
+// 3. Log the checkout event used by the time reports
+// 4. Record the checkout in the navigation history used by back/forward
+// It also prunes inactive branches when gitbm.pruneAfterDays is set
+//
+// It runs on every checkout, so it prints nothing unless --verbose is set (GITBM_VERBOSE=1 for
+// the hook). Failures are appended to the hook log instead, see gitbm doctor --hook-log.
 var trackCheckoutCmd = &cobra.Command{
 	Use:    "track-checkout",
 	Short:  "Internal command to track the checkouts of a branch",
 	Long:   `You should not be using this!`,
 	Hidden: true,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Validate basic
-		err := utils.ValidateBasic()
-		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
-		}
-
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// Validate incoming args
 		if len(args) != 2 {
-			logger.PrintError("Invalid number of arguments")
-			os.Exit(1)
+			return errors.New("invalid number of arguments")
 		}
+		branch := args[0]
 
-		// Get db
-		currentDir, _ := os.Getwd()
-		dbPath := dbutils.GetDBPath(currentDir)
-		db, err := db.GetDB(dbPath)
-
+		hookLog, err := logger.OpenLogFile(app.hookLogPath())
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			return err
 		}
+		defer hookLog.Close()
 
-		defer db.Close()
+		// Logs a failure, printing it only with --verbose
+		fail := func(err error) {
+			hookLog.Error(err.Error(), "command", "track-checkout", "branch", branch)
+			if verboseFlag {
+				logger.PrintError("%v", err)
+			}
+		}
 
-		// Get the branch name
-		branchCheckoutRepo := models.NewBranchCheckoutRepository(db)
-		branchCheckout := &models.BranchCheckout{
-			Name:            args[0],
-			LatestCommitMsg: args[1],
+		client, err := app.Client()
+		if err != nil {
+			fail(err)
+			return errReported
 		}
-		err = branchCheckoutRepo.Upsert(branchCheckout)
+		defer client.Close()
 
+		// Checkouts done by gitbm back/forward only move the navigation cursor
+		navigating := os.Getenv(gitutils.SkipNavigationEnv) != ""
+
+		err = client.RecordCheckout(branch, args[1], navigating)
 		if err != nil {
-			logger.PrintError(fmt.Sprint(err))
-			os.Exit(1)
+			fail(err)
+			return errReported
+		}
+		logger.PrintDebug("Tracked checkout of %s", branch)
+
+		// Automatically prune inactive branches, if enabled
+		if err := autoPrune(client); err != nil {
+			fail(err)
 		}
+		return nil
 	},
 }
 
+// Path of the log of the failures of the post-checkout hook
+func (a *App) hookLogPath() string {
+	return filepath.Join(gitutils.GetGitDir(a.Dir), "gitbm-hook.log")
+}
+
 func init() {
 	rootCmd.AddCommand(trackCheckoutCmd)
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var undoSkipFlag bool
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [--skip]",
+	Short: "Undo the last change to the bookmarks, bookmark groups or checkout stats",
+	Long: `
+Undo the last change made by create, delete, add, new, remove, switch, note, tag, prune
+or reset checkouts. Every change they make is recorded in an undo log (see 'gitbm oplog'),
+run 'gitbm undo' again to undo the change before it, and 'gitbm redo' to apply an undone
+change again. Like in an editor, undone changes can no longer be redone once something
+else changes.
+
+Deleting a bookmark group is undone along with its bookmarks, their notes and labels.
+Resetting or pruning checkout stats is undone by adding them back to the stats of the
+branches checked out since.
+
+A change conflicting with what was changed outside of the undo log, eg. by an older
+version of gitbm, is reported and left as is. Use --skip to leave it out of the undo
+log, so that the changes before it can be undone.
+
+Usage:
+  gitbm undo [--skip]
+
+Examples:
+  gitbm undo
+  gitbm undo --skip
+
+Note: This command must be run from within a Git repository initialized with gitbm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if undoSkipFlag {
+			op, err := client.SkipUndo()
+			if err != nil {
+				return err
+			}
+			logger.PrintSuccess("Skipped #%d: %s", op.ID, op.Description)
+			return nil
+		}
+
+		op, err := client.Undo()
+		if errors.Is(err, gitbm.ErrNothingToUndo) {
+			logger.PrintInfo("Nothing to undo")
+			return nil
+		}
+		var conflict *gitbm.ConflictError
+		if errors.As(err, &conflict) {
+			return withRemedies(err, "Run 'gitbm undo --skip' to leave it as is and undo the changes before it")
+		}
+		if err != nil {
+			return err
+		}
+
+		logger.PrintSuccess("Undid #%d: %s", op.ID, op.Description)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolVar(&undoSkipFlag, "skip", false, "Leave the last change as is, so that the changes before it can be undone")
+}
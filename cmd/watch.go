@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/logger"
+	"github.com/devadathanmb/gitbm/internal/utils"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+	"github.com/spf13/cobra"
+)
+
+var watchDaemonFlag bool
+var watchStopFlag bool
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [--daemon | --stop]",
+	Short: "Track checkouts by watching HEAD instead of using the post-checkout hook",
+	Long: `
+Track checkouts by watching the HEAD file and the reflog of the repository, instead of
+using the post-checkout hook.
+
+Unlike the hook, it does not need gitbm in the PATH of every tool running git, prints
+nothing on checkout, and sees the checkouts of tools that skip hooks (eg. some IDEs,
+or git -c core.hooksPath=/dev/null).
+
+By default it runs in the foreground until interrupted, printing the checkouts it records.
+Use --daemon to run it in the background, logging to .git/gitbm-watch.log, and --stop
+to stop it. Only one watcher runs per repository.
+
+It can run along with the hook: checkouts already recorded by the hook are not recorded
+twice. To track checkouts only with it, use 'gitbm init --tracking=watch', which removes
+the hook and starts the watcher in the background.
+
+Usage:
+  gitbm watch [--daemon | --stop]
+
+Examples:
+  gitbm watch
+  gitbm watch --daemon
+  gitbm watch --stop
+
+Note: This command must be run from within a Git repository initialized with gitbm.
+The watcher has to be started again after a reboot, eg. from your shell profile.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+
+		if watchStopFlag {
+			client.Close()
+			stopped, err := app.stopWatchDaemon()
+			if err != nil {
+				return err
+			}
+			if !stopped {
+				logger.PrintInfo("gitbm watch is not running")
+				return nil
+			}
+			logger.PrintSuccess("Stopped gitbm watch")
+			return nil
+		}
+
+		// The pid file of a watcher started with --daemon is written before it starts
+		if pid, running := app.runningWatchPid(); running && pid != os.Getpid() {
+			client.Close()
+			return fmt.Errorf("gitbm watch is already running (pid %d)", pid)
+		}
+
+		if watchDaemonFlag {
+			client.Close()
+			pid, err := app.startWatchDaemon()
+			if err != nil {
+				return err
+			}
+			logger.PrintSuccess("Started gitbm watch in the background (pid %d), logging to %s", pid, app.watchLogPath())
+			return nil
+		}
+
+		defer client.Close()
+
+		pidPath := app.watchPidPath()
+		if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", pidPath, err)
+		}
+		defer os.Remove(pidPath)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if client.Tracking() == gitbm.TrackingHook {
+			logger.PrintInfo("The post-checkout hook is installed too, checkouts it records are not recorded twice")
+		}
+		logger.PrintInfo("Watching for checkouts in %s, press Ctrl+C to stop", client.Dir())
+
+		err = client.Watch(ctx, func(branch string, err error) {
+			timestamp := time.Now().Format("2006-01-02 15:04:05")
+			if err != nil {
+				logger.PrintError("%s Error recording checkout of %s: %v", timestamp, branch, err)
+				return
+			}
+			logger.Print("%s Recorded checkout of %s", timestamp, branch)
+
+			// Automatically prune inactive branches, if enabled
+			if err := autoPrune(client); err != nil {
+				logger.PrintWarning("%s %v", timestamp, err)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+// Path of the file holding the pid of the running watcher
+func (a *App) watchPidPath() string {
+	return filepath.Join(gitutils.GetGitDir(a.Dir), "gitbm-watch.pid")
+}
+
+// Path of the log of the watcher running in the background
+func (a *App) watchLogPath() string {
+	return filepath.Join(gitutils.GetGitDir(a.Dir), "gitbm-watch.log")
+}
+
+// Gets the pid of the watcher of the repository, and whether it is running
+func (a *App) runningWatchPid() (int, bool) {
+	data, err := os.ReadFile(a.watchPidPath())
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	return pid, utils.IsProcessRunning(pid)
+}
+
+// Starts gitbm watch in the background, detached from the terminal, returning its pid
+func (a *App) startWatchDaemon() (int, error) {
+	// The watcher runs in a process of its own, which would not use the database of the app
+	if a.DB != nil {
+		return 0, errors.New("gitbm watch can not run in the background on the database of the app")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("error finding the gitbm executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(a.watchLogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("error opening the watch log: %w", err)
+	}
+	defer logFile.Close()
+
+	daemon := exec.Command(executable, "watch")
+	daemon.Dir = a.Dir
+	daemon.Stdout = logFile
+	daemon.Stderr = logFile
+	utils.DetachCommand(daemon)
+
+	if err := daemon.Start(); err != nil {
+		return 0, fmt.Errorf("error starting gitbm watch: %w", err)
+	}
+	pid := daemon.Process.Pid
+	daemon.Process.Release()
+
+	// Write the pid file right away, so that the watcher can be stopped before it is up
+	if err := os.WriteFile(a.watchPidPath(), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return 0, fmt.Errorf("error writing %s: %w", a.watchPidPath(), err)
+	}
+	return pid, nil
+}
+
+// Stops the watcher of the repository, returning false if it was not running
+func (a *App) stopWatchDaemon() (bool, error) {
+	pid, running := a.runningWatchPid()
+	if !running {
+		// Remove the pid file left by a watcher that did not exit cleanly
+		os.Remove(a.watchPidPath())
+		return false, nil
+	}
+
+	if err := utils.StopProcess(pid); err != nil {
+		return false, fmt.Errorf("error stopping gitbm watch (pid %d): %w", pid, err)
+	}
+	return true, nil
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().BoolVar(&watchDaemonFlag, "daemon", false, "Run in the background, logging to .git/gitbm-watch.log")
+	watchCmd.Flags().BoolVar(&watchStopFlag, "stop", false, "Stop the watcher running in the background")
+	watchCmd.MarkFlagsMutuallyExclusive("daemon", "stop")
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// How long a backup waits for the writers of the source database before giving up
+const backupTimeout = 30 * time.Second
+
+// Copy the database into a new database file at path, with sqlite's online backup API:
+// the copy is consistent even if other processes (eg. the hook) write meanwhile.
+func Backup(database *sql.DB, path string) error {
+	backup, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("error creating backup: %w", err)
+	}
+	defer backup.Close()
+
+	if err := copyDB(database, backup); err != nil {
+		return fmt.Errorf("error creating backup: %w", err)
+	}
+	return nil
+}
+
+// Replace the content of the database with the database file at path, eg. made by Backup.
+// The schema is the one of the backup, it is migrated the next time the database is opened.
+func Restore(database *sql.DB, path string) error {
+	backup, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("error opening backup: %w", err)
+	}
+	defer backup.Close()
+
+	if err := copyDB(backup, database); err != nil {
+		return fmt.Errorf("error restoring backup: %w", err)
+	}
+	return nil
+}
+
+// Copy the main database of src into dest, page by page
+func copyDB(src *sql.DB, dest *sql.DB) error {
+	ctx := context.Background()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return fmt.Errorf("not a sqlite connection")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			// Step returns false without an error while another connection holds a lock
+			deadline := time.Now().Add(backupTimeout)
+			for {
+				done, err := backup.Step(-1)
+				if err != nil {
+					backup.Close()
+					return err
+				}
+				if done {
+					break
+				}
+				if time.Now().After(deadline) {
+					backup.Close()
+					return fmt.Errorf("timed out waiting for the database to be unlocked")
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+			return backup.Finish()
+		})
+	})
+}
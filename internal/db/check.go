@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// A row referencing a row that does not exist, as reported by PRAGMA foreign_key_check
+type ForeignKeyViolation struct {
+	Table  string
+	RowID  int64
+	Parent string // Table of the missing row
+}
+
+// Check the integrity of the database file, returning the problems found (none if it is fine)
+func CheckIntegrity(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("PRAGMA integrity_check;")
+	if err != nil {
+		return nil, fmt.Errorf("error checking database integrity: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return problems, nil
+}
+
+// Find the rows referencing rows that do not exist. They are left behind when
+// rows are deleted while foreign keys are not enforced, eg. by older versions of gitbm.
+func CheckForeignKeys(db *sql.DB) ([]ForeignKeyViolation, error) {
+	rows, err := db.Query("PRAGMA foreign_key_check;")
+	if err != nil {
+		return nil, fmt.Errorf("error checking foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	var violations []ForeignKeyViolation
+	for rows.Next() {
+		var v ForeignKeyViolation
+		var rowID sql.NullInt64
+		var fkID int64
+		if err := rows.Scan(&v.Table, &rowID, &v.Parent, &fkID); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		v.RowID = rowID.Int64
+		violations = append(violations, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return violations, nil
+}
+
+// Delete the rows violating foreign keys in one transaction
+func DeleteViolations(db *sql.DB, violations []ForeignKeyViolation) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, v := range violations {
+		// Table names come from sqlite itself, they can not be bound parameters
+		query := fmt.Sprintf("DELETE FROM %s WHERE rowid = ?", quoteIdentifier(v.Table))
+		if _, err = tx.Exec(query, v.RowID); err != nil {
+			return fmt.Errorf("error deleting row %d of %s: %w", v.RowID, v.Table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -2,22 +2,38 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Settings of every connection, as database/sql opens new connections as needed:
+//   - foreign keys, as sqlite does not enforce foreign key constraints by default
+//   - WAL journal mode, so that reading (eg. gitbm recent) and the hook writing do not block each other
+//   - a busy timeout, so that concurrent writers (eg. the hook firing on every checkout of a
+//     scripted rebase) wait for each other instead of failing with "database is locked"
+//   - immediate transactions, taking the write lock upfront, as a transaction that reads and
+//     then writes fails right away, without waiting, if another connection wrote in between
+const connectionParams = "_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+
 // Function to get the database connection
 func GetDB(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	db, err := sql.Open("sqlite3", path+"?"+connectionParams)
 	if err != nil {
 		return nil, err
 	}
 
-	// Always enable foreign key constraints before returning the database connection
-	// as sqlite does not enforce foreign key constraints by default
-	_, err = db.Exec("PRAGMA foreign_keys = ON;")
+	// Open a connection right away, sql.Open does not
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Bring databases created by older versions of gitbm up to date
+	err = migrateDB(db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -25,21 +41,13 @@ func GetDB(path string) (*sql.DB, error) {
 }
 
 // Function to initialize the database
-func InitDB(path string) error {
-	var err error
+func InitDB(path string) (err error) {
 	db, err := GetDB(path)
 
 	if err != nil {
 		return err
 	}
 
-	// Start a transaction
-	tx, err := db.Begin()
-
-	if err != nil {
-		return err
-	}
-
 	// After initing the database
 	// close the connection and if there is an error remove the database file
 	defer func() {
@@ -49,6 +57,20 @@ func InitDB(path string) error {
 		}
 	}()
 
+	return SetupDB(db)
+}
+
+// Create the tables of a database opened by the caller, eg. an in-memory database,
+// and bring them up to date. Tables which exist already are left as is.
+func SetupDB(db *sql.DB) error {
+	// Start a transaction
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Enable foreign key constraints
 	_, err = tx.Exec("PRAGMA foreign_keys = ON;")
 	if err != nil {
@@ -117,5 +139,144 @@ func InitDB(path string) error {
 		return err
 	}
 
+	// Apply the schema changes made after the initial tables
+	err = migrateDB(db)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Schema changes made after the initial release of gitbm.
+// The index of each migration + 1 is the schema version it brings the database to,
+// so new migrations must only ever be appended to this list.
+var migrations = []string{
+	// 1: Free-text notes on bookmarked branches
+	`ALTER TABLE branches ADD COLUMN notes TEXT NOT NULL DEFAULT '';`,
+
+	// 2: Many-to-many labels on bookmarked branches
+	`
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE IF NOT EXISTS branch_tags (
+		branch_id INTEGER NOT NULL,
+		tag_id INTEGER NOT NULL,
+		PRIMARY KEY (branch_id, tag_id),
+		FOREIGN KEY (branch_id) REFERENCES branches(id) ON DELETE CASCADE,
+		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_branch_tags_tag_id ON branch_tags(tag_id);
+	`,
+
+	// 3: Smart bookmark groups, whose branches are defined by a rule instead of branches rows
+	`
+	ALTER TABLE bookmark_group ADD COLUMN rule_type TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookmark_group ADD COLUMN rule TEXT NOT NULL DEFAULT '';
+	`,
+
+	// 4: Browser-style navigation history of checkouts, with a singleton cursor
+	`
+	CREATE TABLE IF NOT EXISTS navigation_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		branch_name TEXT NOT NULL,
+		visited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS navigation_cursor (
+		id INTEGER PRIMARY KEY,
+		history_id INTEGER NOT NULL
+	);
+	`,
+
+	// 5: Log of every checkout, to compute the time spent on each branch
+	`
+	CREATE TABLE IF NOT EXISTS checkout_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		branch_name TEXT NOT NULL,
+		checked_out_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_checkout_events_checked_out_at ON checkout_events(checked_out_at);
+	`,
+
+	// 6: Checkout events imported from the reflog, deduplicated so that imports can be re-run
+	`
+	ALTER TABLE checkout_events ADD COLUMN source TEXT NOT NULL DEFAULT 'hook';
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_checkout_events_branch_time ON checkout_events(branch_name, checked_out_at);
+	`,
+
+	// 7: Remote a bookmarked branch was added from, to create its local tracking branch on checkout
+	`ALTER TABLE branches ADD COLUMN remote TEXT NOT NULL DEFAULT '';`,
+
+	// 8: Bookmarks of tags, commits and other refs, checked out as a detached HEAD
+	`ALTER TABLE branches ADD COLUMN kind TEXT NOT NULL DEFAULT 'branch';`,
+
+	// 9: Undo log of the commands changing bookmarks, groups and checkout stats
+	`
+	CREATE TABLE IF NOT EXISTS operations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		command TEXT NOT NULL,
+		description TEXT NOT NULL,
+		steps TEXT NOT NULL,
+		state TEXT NOT NULL DEFAULT 'done',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_operations_state ON operations(state);
+	`,
+}
+
+// Function to apply pending migrations, tracked through sqlite's user_version pragma
+func migrateDB(db *sql.DB) error {
+	// Nothing to migrate if the database has not been initialized yet
+	var tableCount int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'branches'").Scan(&tableCount)
+	if err != nil {
+		return fmt.Errorf("error checking database schema: %w", err)
+	}
+	if tableCount == 0 {
+		return nil
+	}
+
+	var version int
+	err = db.QueryRow("PRAGMA user_version;").Scan(&version)
+	if err != nil {
+		return fmt.Errorf("error getting schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("error starting migration: %w", err)
+		}
+
+		// Another process may have applied it while this one was waiting for the lock
+		var current int
+		if err = tx.QueryRow("PRAGMA user_version;").Scan(&current); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error getting schema version: %w", err)
+		}
+		if current > i {
+			tx.Rollback()
+			continue
+		}
+
+		if _, err = tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %w", i+1, err)
+		}
+
+		// Pragmas can not take bound parameters
+		if _, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d;", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error updating schema version: %w", err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("error committing migration %d: %w", i+1, err)
+		}
+	}
+
 	return nil
 }
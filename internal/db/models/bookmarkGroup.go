@@ -5,23 +5,40 @@ import (
 	"fmt"
 )
 
+// Rule types of smart bookmark groups
+const (
+	RuleGlob     = "glob"     // Branch names matching a git for-each-ref pattern
+	RuleRegex    = "regex"    // Branch names matching a regular expression
+	RuleMerged   = "merged"   // Branches merged into a ref
+	RuleUnmerged = "unmerged" // Branches not merged into a ref
+	RuleAuthor   = "author"   // Branches whose last commit author matches
+)
+
 type BookmarkGroup struct {
-	ID   int64
-	Name string
+	ID       int64
+	Name     string
+	RuleType string // Empty for explicit bookmark groups
+	Rule     string
+}
+
+// Smart bookmark groups are evaluated live against the git refs
+// instead of holding explicitly added branches
+func (bg BookmarkGroup) IsSmart() bool {
+	return bg.RuleType != ""
 }
 
 type BookmarkGroupRepository struct {
-	db *sql.DB
+	db Querier
 }
 
-func NewBookmarkGroupRepository(db *sql.DB) *BookmarkGroupRepository {
+func NewBookmarkGroupRepository(db Querier) *BookmarkGroupRepository {
 	return &BookmarkGroupRepository{db: db}
 }
 
 // Create a new bookmark group and set it as the current bookmark group
 func (r *BookmarkGroupRepository) Create(bg *BookmarkGroup) error {
 	// Start a transaction
-	tx, err := r.db.Begin()
+	tx, err := begin(r.db)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
@@ -32,7 +49,7 @@ func (r *BookmarkGroupRepository) Create(bg *BookmarkGroup) error {
 	}()
 
 	// Insert the new bookmark group
-	result, err := tx.Exec("INSERT INTO bookmark_group (name) VALUES (?)", bg.Name)
+	result, err := tx.Exec("INSERT INTO bookmark_group (name, rule_type, rule) VALUES (?, ?, ?)", bg.Name, bg.RuleType, bg.Rule)
 	if err != nil {
 		return fmt.Errorf("error inserting bookmark group: %w", err)
 	}
@@ -62,7 +79,7 @@ func (r *BookmarkGroupRepository) Create(bg *BookmarkGroup) error {
 
 // List all bookmark groups
 func (r *BookmarkGroupRepository) List() ([]BookmarkGroup, error) {
-	query := "SELECT id, name FROM bookmark_group"
+	query := "SELECT id, name, rule_type, rule FROM bookmark_group"
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("error querying bookmark groups: %w", err)
@@ -72,7 +89,7 @@ func (r *BookmarkGroupRepository) List() ([]BookmarkGroup, error) {
 	var bookmarkGroups []BookmarkGroup
 	for rows.Next() {
 		var group BookmarkGroup
-		if err := rows.Scan(&group.ID, &group.Name); err != nil {
+		if err := rows.Scan(&group.ID, &group.Name, &group.RuleType, &group.Rule); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 		bookmarkGroups = append(bookmarkGroups, group)
@@ -87,13 +104,13 @@ func (r *BookmarkGroupRepository) List() ([]BookmarkGroup, error) {
 // Get current bookmark group
 func (r *BookmarkGroupRepository) GetCurrent() (*BookmarkGroup, error) {
 	query := `
-		SELECT bg.id, bg.name 
+		SELECT bg.id, bg.name, bg.rule_type, bg.rule
 		FROM bookmark_group bg
 		JOIN current_bookmark_group cbg ON bg.id = cbg.bookmark_group_id
 		WHERE cbg.id = 1
 	`
 	var bg BookmarkGroup
-	err := r.db.QueryRow(query).Scan(&bg.ID, &bg.Name)
+	err := r.db.QueryRow(query).Scan(&bg.ID, &bg.Name, &bg.RuleType, &bg.Rule)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no current bookmark group set")
@@ -104,15 +121,29 @@ func (r *BookmarkGroupRepository) GetCurrent() (*BookmarkGroup, error) {
 }
 
 func (r *BookmarkGroupRepository) Delete(bookmarkGroupName string) error {
+	tx, err := begin(r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Unset the group if it is the current one, its reference can not be set to NULL
+	_, err = tx.Exec("DELETE FROM current_bookmark_group WHERE bookmark_group_id IN (SELECT id FROM bookmark_group WHERE name = ?)", bookmarkGroupName)
+	if err != nil {
+		return err
+	}
+
 	query := "DELETE FROM bookmark_group WHERE name = ?"
-	_, err := r.db.Exec(query, bookmarkGroupName)
-	return err
+	if _, err = tx.Exec(query, bookmarkGroupName); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *BookmarkGroupRepository) GetByName(name string) (*BookmarkGroup, error) {
-	query := "SELECT id, name FROM bookmark_group WHERE name = ?"
+	query := "SELECT id, name, rule_type, rule FROM bookmark_group WHERE name = ?"
 	var bg BookmarkGroup
-	err := r.db.QueryRow(query, name).Scan(&bg.ID, &bg.Name)
+	err := r.db.QueryRow(query, name).Scan(&bg.ID, &bg.Name, &bg.RuleType, &bg.Rule)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("bookmark group '%s' not found", name)
@@ -121,3 +152,16 @@ func (r *BookmarkGroupRepository) GetByName(name string) (*BookmarkGroup, error)
 	}
 	return &bg, nil
 }
+
+func (r *BookmarkGroupRepository) GetByID(id int64) (*BookmarkGroup, error) {
+	query := "SELECT id, name, rule_type, rule FROM bookmark_group WHERE id = ?"
+	var bg BookmarkGroup
+	err := r.db.QueryRow(query, id).Scan(&bg.ID, &bg.Name, &bg.RuleType, &bg.Rule)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("bookmark group with id %d not found", id)
+		}
+		return nil, fmt.Errorf("error getting bookmark group: %w", err)
+	}
+	return &bg, nil
+}
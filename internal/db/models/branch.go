@@ -3,35 +3,81 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mattn/go-sqlite3"
 )
 
+// What a bookmark points to. Anything but a branch is checked out as a detached HEAD.
+const (
+	BranchKindBranch = "branch"
+	BranchKindTag    = "tag"    // Name is the name of the tag, eg. v1.2.0
+	BranchKindCommit = "commit" // Name is the full object name of the commit
+	BranchKindRef    = "ref"    // Name is any ref or revision git resolves, eg. refs/bisect/bad
+)
+
 type Branch struct {
 	ID              int64
 	BookmarkGroupID int64
 	Name            string
 	Alias           string
+	Notes           string
+	Remote          string   // Remote the branch was bookmarked from, empty for local branches
+	Kind            string   // One of the BranchKind constants, branch if empty
+	Tags            []string // Only filled in by TagRepository.LoadForBranches
+	Tickets         []string // Ticket keys found in the name, not stored
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 }
 
+// Columns selected for a Branch, in the order expected by scanBranch
+const branchColumns = "id, bookmark_group_id, name, branch_alias, notes, remote, kind, created_at, updated_at"
+
+// Whether the bookmark is a branch, as opposed to a tag, commit or ref
+func (b Branch) IsBranch() bool {
+	return b.Kind == "" || b.Kind == BranchKindBranch
+}
+
+// The revision git checks out for the bookmark, qualified so that eg. a tag
+// is not mistaken for a branch with the same name
+func (b Branch) Rev() string {
+	switch b.Kind {
+	case BranchKindTag:
+		return "refs/tags/" + b.Name
+	case BranchKindCommit, BranchKindRef:
+		return b.Name
+	default:
+		return "refs/heads/" + b.Name
+	}
+}
+
+// Name of the bookmark for display, with commits abbreviated
+func (b Branch) DisplayName() string {
+	if b.Kind == BranchKindCommit && len(b.Name) > 12 {
+		return b.Name[:12]
+	}
+	return b.Name
+}
+
 type BranchRepository struct {
-	db *sql.DB
+	db Querier
 }
 
-func NewBranchRepository(db *sql.DB) *BranchRepository {
+func NewBranchRepository(db Querier) *BranchRepository {
 	return &BranchRepository{db: db}
 }
 
 func (r *BranchRepository) Create(b *Branch) error {
 	query := `
-        INSERT INTO branches (bookmark_group_id, name, branch_alias, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?)
+        INSERT INTO branches (bookmark_group_id, name, branch_alias, notes, remote, kind, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
     `
+	if b.Kind == "" {
+		b.Kind = BranchKindBranch
+	}
 	now := time.Now()
-	result, err := r.db.Exec(query, b.BookmarkGroupID, b.Name, b.Alias, now, now)
+	result, err := r.db.Exec(query, b.BookmarkGroupID, b.Name, b.Alias, b.Notes, b.Remote, b.Kind, now, now)
 	if err != nil {
 		if sqliteErr, ok := err.(sqlite3.Error); ok {
 			if sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -50,32 +96,29 @@ func (r *BranchRepository) Create(b *Branch) error {
 }
 
 func (r *BranchRepository) ListByBookmarkGroupId(bookmarkGroupID int64) ([]Branch, error) {
-	query := "SELECT id, name, branch_alias, created_at, updated_at FROM branches WHERE bookmark_group_id = ?"
+	query := "SELECT " + branchColumns + " FROM branches WHERE bookmark_group_id = ?"
 	rows, err := r.db.Query(query, bookmarkGroupID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying branches: %w", err)
 	}
 	defer rows.Close()
-	var branches []Branch
-	for rows.Next() {
-		var branch Branch
-		err := rows.Scan(&branch.ID, &branch.Name, &branch.Alias, &branch.CreatedAt, &branch.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning row: %w", err)
-		}
-		branch.BookmarkGroupID = bookmarkGroupID
-		branches = append(branches, branch)
-	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	return scanBranches(rows)
+}
+
+// List bookmarked branches of all bookmark groups
+func (r *BranchRepository) ListAll() ([]Branch, error) {
+	query := "SELECT " + branchColumns + " FROM branches ORDER BY bookmark_group_id, name"
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying branches: %w", err)
 	}
-	return branches, nil
+	defer rows.Close()
+	return scanBranches(rows)
 }
 
 func (r *BranchRepository) GetByName(bookmarkGroupID int64, name string) (*Branch, error) {
-	query := "SELECT id, branch_alias, created_at, updated_at FROM branches WHERE bookmark_group_id = ? AND name = ?"
-	b := &Branch{BookmarkGroupID: bookmarkGroupID, Name: name}
-	err := r.db.QueryRow(query, bookmarkGroupID, name).Scan(&b.ID, &b.Alias, &b.CreatedAt, &b.UpdatedAt)
+	query := "SELECT " + branchColumns + " FROM branches WHERE bookmark_group_id = ? AND name = ?"
+	b, err := scanBranch(r.db.QueryRow(query, bookmarkGroupID, name))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("branch '%s' not found in this bookmark group", name)
@@ -93,3 +136,158 @@ func (r *BranchRepository) Remove(bookmarkGroupID int64, name string) error {
 	}
 	return nil
 }
+
+// Remove stale data in one transaction: the bookmarks with the given IDs
+// and the checkout stats of the given branch names
+func (r *BranchRepository) Prune(branchIDs []int64, checkoutNames []string) (err error) {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, id := range branchIDs {
+		if _, err = tx.Exec("DELETE FROM branches WHERE id = ?", id); err != nil {
+			return fmt.Errorf("error removing branch: %w", err)
+		}
+	}
+
+	for _, name := range checkoutNames {
+		if _, err = tx.Exec("DELETE FROM branch_checkouts WHERE name = ?", name); err != nil {
+			return fmt.Errorf("error removing branch checkouts: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing prune: %w", err)
+	}
+	return nil
+}
+
+// Replace the notes of a bookmarked branch
+func (r *BranchRepository) UpdateNotes(bookmarkGroupID int64, name string, notes string) error {
+	query := "UPDATE branches SET notes = ?, updated_at = ? WHERE bookmark_group_id = ? AND name = ?"
+	result, err := r.db.Exec(query, notes, time.Now(), bookmarkGroupID, name)
+	if err != nil {
+		return fmt.Errorf("error updating notes: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error updating notes: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("branch '%s' not found in this bookmark group", name)
+	}
+	return nil
+}
+
+// Search the notes of bookmarked branches across all bookmark groups.
+// Every whitespace separated term of the query must appear in the notes (case-insensitive).
+func (r *BranchRepository) SearchNotes(query string) ([]Branch, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	conditions := make([]string, 0, len(terms))
+	params := make([]interface{}, 0, len(terms))
+	for _, term := range terms {
+		conditions = append(conditions, `notes LIKE ? ESCAPE '\'`)
+		params = append(params, "%"+escapeLike(term)+"%")
+	}
+
+	sqlQuery := `
+		SELECT ` + branchColumns + `
+		FROM branches
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY bookmark_group_id, name
+	`
+	rows, err := r.db.Query(sqlQuery, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching notes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBranches(rows)
+}
+
+// List bookmarked branches across all bookmark groups having every one of the given tags
+func (r *BranchRepository) ListByTags(tags []string) ([]Branch, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, 0, len(tags))
+	params := make([]interface{}, 0, len(tags)+1)
+	for _, tag := range tags {
+		placeholders = append(placeholders, "?")
+		params = append(params, tag)
+	}
+	params = append(params, len(tags))
+
+	query := `
+		SELECT ` + prefixColumns("b", branchColumns) + `
+		FROM branches b
+		JOIN branch_tags bt ON bt.branch_id = b.id
+		JOIN tags t ON t.id = bt.tag_id
+		WHERE t.name IN (` + strings.Join(placeholders, ", ") + `)
+		GROUP BY b.id
+		HAVING COUNT(DISTINCT t.id) = ?
+		ORDER BY b.bookmark_group_id, b.name
+	`
+	rows, err := r.db.Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying branches: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBranches(rows)
+}
+
+// Escape the LIKE wildcards so that search terms are matched literally
+func escapeLike(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(s)
+}
+
+// Anything a single branch row can be scanned from, ie. *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBranch(row rowScanner) (*Branch, error) {
+	var b Branch
+	err := row.Scan(&b.ID, &b.BookmarkGroupID, &b.Name, &b.Alias, &b.Notes, &b.Remote, &b.Kind, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func scanBranches(rows *sql.Rows) ([]Branch, error) {
+	var branches []Branch
+	for rows.Next() {
+		branch, err := scanBranch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		branches = append(branches, *branch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return branches, nil
+}
+
+// Qualify a comma separated column list with a table alias
+func prefixColumns(alias string, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, part := range parts {
+		parts[i] = alias + "." + part
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,7 +1,7 @@
 package models
 
 import (
-	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -14,10 +14,10 @@ type BranchCheckout struct {
 }
 
 type BranchCheckoutRepository struct {
-	db *sql.DB
+	db Querier
 }
 
-func NewBranchCheckoutRepository(db *sql.DB) *BranchCheckoutRepository {
+func NewBranchCheckoutRepository(db Querier) *BranchCheckoutRepository {
 	return &BranchCheckoutRepository{db: db}
 }
 
@@ -131,3 +131,78 @@ func (r *BranchCheckoutRepository) DeleteAll() error {
 	_, err := r.db.Exec(query)
 	return err
 }
+
+// Remove the checkout stats of the given branches
+func (r *BranchCheckoutRepository) DeleteByNames(names []string) (err error) {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, name := range names {
+		if _, err = tx.Exec("DELETE FROM branch_checkouts WHERE name = ?", name); err != nil {
+			return fmt.Errorf("error removing branch checkouts: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Put back removed checkout stats. They are merged with the stats of the branches
+// checked out since: the counts add up and the latest checkout wins.
+func (r *BranchCheckoutRepository) Restore(checkouts []BranchCheckout) (err error) {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := `
+		INSERT INTO branch_checkouts (name, checkout_count, last_checked_out_at, latest_commit_msg)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			checkout_count = checkout_count + excluded.checkout_count,
+			latest_commit_msg = CASE WHEN excluded.last_checked_out_at > last_checked_out_at
+				THEN excluded.latest_commit_msg ELSE latest_commit_msg END,
+			last_checked_out_at = MAX(last_checked_out_at, excluded.last_checked_out_at)
+	`
+	for _, b := range checkouts {
+		if _, err = tx.Exec(query, b.Name, b.CheckoutCount, b.LastCheckedOutAt, b.LatestCommitMsg); err != nil {
+			return fmt.Errorf("error restoring branch checkouts: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// List the checkout stats of all branches ever checked out
+func (r *BranchCheckoutRepository) ListAll() ([]BranchCheckout, error) {
+	rows, err := r.db.Query(`SELECT * FROM branch_checkouts ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying branch checkouts: %w", err)
+	}
+	defer rows.Close()
+
+	var branches []BranchCheckout
+	for rows.Next() {
+		var b BranchCheckout
+		err := rows.Scan(&b.ID, &b.Name, &b.CheckoutCount, &b.LastCheckedOutAt, &b.LatestCommitMsg)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		branches = append(branches, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return branches, nil
+}
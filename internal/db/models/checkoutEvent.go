@@ -0,0 +1,239 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Sources of checkout events
+const (
+	CheckoutSourceHook   = "hook"   // Recorded by the post-checkout hook
+	CheckoutSourceReflog = "reflog" // Imported from the git reflog
+	CheckoutSourceWatch  = "watch"  // Recorded by gitbm watch
+)
+
+type CheckoutEvent struct {
+	ID           int64
+	BranchName   string
+	CheckedOutAt time.Time
+	Source       string
+}
+
+type CheckoutEventRepository struct {
+	db Querier
+}
+
+func NewCheckoutEventRepository(db Querier) *CheckoutEventRepository {
+	return &CheckoutEventRepository{db: db}
+}
+
+func (r *CheckoutEventRepository) Create(e *CheckoutEvent) error {
+	if e.CheckedOutAt.IsZero() {
+		e.CheckedOutAt = time.Now()
+	}
+
+	if e.Source == "" {
+		e.Source = CheckoutSourceHook
+	}
+
+	// Timestamps are stored in UTC so that they compare correctly as text
+	e.CheckedOutAt = e.CheckedOutAt.UTC()
+
+	query := "INSERT INTO checkout_events (branch_name, checked_out_at, source) VALUES (?, ?, ?)"
+	result, err := r.db.Exec(query, e.BranchName, e.CheckedOutAt, e.Source)
+	if err != nil {
+		return fmt.Errorf("error recording checkout event: %w", err)
+	}
+	e.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	return nil
+}
+
+// List the checkout events between since and until, oldest first.
+// The last event before since is included as well, as the time spent on
+// that branch carries over into the period.
+func (r *CheckoutEventRepository) ListBetween(since time.Time, until time.Time) ([]CheckoutEvent, error) {
+	query := `
+		SELECT id, branch_name, checked_out_at FROM (
+			SELECT id, branch_name, checked_out_at FROM checkout_events
+			WHERE checked_out_at < ?
+			ORDER BY checked_out_at DESC, id DESC
+			LIMIT 1
+		)
+		UNION ALL
+		SELECT id, branch_name, checked_out_at FROM checkout_events
+		WHERE checked_out_at >= ? AND checked_out_at < ?
+		ORDER BY checked_out_at ASC, id ASC
+	`
+	rows, err := r.db.Query(query, since.UTC(), since.UTC(), until.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error querying checkout events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []CheckoutEvent
+	for rows.Next() {
+		var e CheckoutEvent
+		if err := rows.Scan(&e.ID, &e.BranchName, &e.CheckedOutAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return events, nil
+}
+
+// List the checkout events recorded after the event with the given ID, oldest first
+func (r *CheckoutEventRepository) ListAfter(id int64) ([]CheckoutEvent, error) {
+	query := "SELECT id, branch_name, checked_out_at, source FROM checkout_events WHERE id > ? ORDER BY id ASC"
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying checkout events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []CheckoutEvent
+	for rows.Next() {
+		var e CheckoutEvent
+		if err := rows.Scan(&e.ID, &e.BranchName, &e.CheckedOutAt, &e.Source); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return events, nil
+}
+
+// Get the ID of the last recorded checkout event, 0 if there is none
+func (r *CheckoutEventRepository) GetLastID() (int64, error) {
+	var id int64
+	err := r.db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM checkout_events").Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error getting last checkout event: %w", err)
+	}
+	return id, nil
+}
+
+// List the checkout events of a branch recorded from the given source within window of at, oldest first
+func (r *CheckoutEventRepository) ListNear(branchName string, source string, at time.Time, window time.Duration) ([]CheckoutEvent, error) {
+	query := `
+		SELECT id, branch_name, checked_out_at, source FROM checkout_events
+		WHERE branch_name = ? AND source = ? AND checked_out_at > ? AND checked_out_at < ?
+		ORDER BY checked_out_at ASC, id ASC
+	`
+	rows, err := r.db.Query(query, branchName, source, at.Add(-window).UTC(), at.Add(window).UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error querying checkout events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []CheckoutEvent
+	for rows.Next() {
+		var e CheckoutEvent
+		if err := rows.Scan(&e.ID, &e.BranchName, &e.CheckedOutAt, &e.Source); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return events, nil
+}
+
+// Get the time of the first checkout recorded as it happened, by the hook or gitbm watch, nil if there is none
+func (r *CheckoutEventRepository) GetFirstTrackedAt() (*time.Time, error) {
+	query := "SELECT checked_out_at FROM checkout_events WHERE source != ? ORDER BY checked_out_at ASC LIMIT 1"
+	var trackedAt time.Time
+	err := r.db.QueryRow(query, CheckoutSourceReflog).Scan(&trackedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting first tracked checkout: %w", err)
+	}
+	return &trackedAt, nil
+}
+
+// Import checkout events from an external source, such as the reflog, in one transaction.
+// Events already present (same branch and time) are skipped, so importing again is a no-op.
+// The checkout counts and last checkout times of the branches are updated for the new events,
+// using commitMsgs for the latest commit message of branches checked out for the first time.
+// It returns the number of imported events.
+func (r *CheckoutEventRepository) Import(events []CheckoutEvent, commitMsgs map[string]string) (int, error) {
+	tx, err := begin(r.db)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	imported := 0
+	counts := make(map[string]int64)
+	latest := make(map[string]time.Time)
+	for _, e := range events {
+		checkedOutAt := e.CheckedOutAt.UTC()
+
+		var result sql.Result
+		result, err = tx.Exec(
+			"INSERT OR IGNORE INTO checkout_events (branch_name, checked_out_at, source) VALUES (?, ?, ?)",
+			e.BranchName, checkedOutAt, e.Source,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("error importing checkout event: %w", err)
+		}
+
+		var affected int64
+		affected, err = result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("error importing checkout event: %w", err)
+		}
+		if affected == 0 {
+			continue
+		}
+
+		imported++
+		counts[e.BranchName]++
+		if checkedOutAt.After(latest[e.BranchName]) {
+			latest[e.BranchName] = checkedOutAt
+		}
+	}
+
+	for name, count := range counts {
+		var lastCheckedOutAt time.Time
+		err = tx.QueryRow("SELECT last_checked_out_at FROM branch_checkouts WHERE name = ?", name).Scan(&lastCheckedOutAt)
+		if err == sql.ErrNoRows {
+			_, err = tx.Exec(
+				"INSERT INTO branch_checkouts (name, checkout_count, last_checked_out_at, latest_commit_msg) VALUES (?, ?, ?, ?)",
+				name, count, latest[name], commitMsgs[name],
+			)
+		} else if err == nil {
+			if latest[name].After(lastCheckedOutAt) {
+				lastCheckedOutAt = latest[name]
+			}
+			_, err = tx.Exec(
+				"UPDATE branch_checkouts SET checkout_count = checkout_count + ?, last_checked_out_at = ? WHERE name = ?",
+				count, lastCheckedOutAt, name,
+			)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error updating branch checkouts: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error committing import: %w", err)
+	}
+	return imported, nil
+}
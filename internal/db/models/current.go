@@ -10,10 +10,10 @@ type CurrentBookmarkGroup struct {
 }
 
 type CurrentBookmarkGroupRepository struct {
-	db *sql.DB
+	db Querier
 }
 
-func NewCurrentBookmarkGroupRepository(db *sql.DB) *CurrentBookmarkGroupRepository {
+func NewCurrentBookmarkGroupRepository(db Querier) *CurrentBookmarkGroupRepository {
 	return &CurrentBookmarkGroupRepository{db: db}
 }
 
@@ -32,10 +32,21 @@ func (r *CurrentBookmarkGroupRepository) GetCurrentBookmarkGroupId() (int64, err
 
 // You might also want to add a method to set the current bookmark group
 func (r *CurrentBookmarkGroupRepository) SetCurrentBookmarkGroupId(bookmarkGroupID int64) error {
-	query := "UPDATE current_bookmark_group SET bookmark_group_id = ? WHERE id = 1"
+	// The row is missing once the current group was deleted
+	query := `INSERT INTO current_bookmark_group (id, bookmark_group_id) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET bookmark_group_id = excluded.bookmark_group_id`
 	_, err := r.db.Exec(query, bookmarkGroupID)
 	if err != nil {
 		return fmt.Errorf("error setting current bookmark group: %w", err)
 	}
 	return nil
 }
+
+// Unset the current bookmark group, eg. when it points to a group that no longer exists
+func (r *CurrentBookmarkGroupRepository) Clear() error {
+	_, err := r.db.Exec("DELETE FROM current_bookmark_group")
+	if err != nil {
+		return fmt.Errorf("error clearing current bookmark group: %w", err)
+	}
+	return nil
+}
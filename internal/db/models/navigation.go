@@ -0,0 +1,179 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Maximum number of entries kept in the navigation history
+const MaxNavigationEntries = 200
+
+type NavigationEntry struct {
+	ID         int64
+	BranchName string
+	VisitedAt  time.Time
+}
+
+type NavigationRepository struct {
+	db Querier
+}
+
+func NewNavigationRepository(db Querier) *NavigationRepository {
+	return &NavigationRepository{db: db}
+}
+
+// Anything a single row can be queried from, ie. *sql.DB or *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Get the id of the history entry the cursor points to, 0 if there is no history
+func getNavigationCursor(q rowQuerier) (int64, error) {
+	var historyID int64
+	err := q.QueryRow("SELECT history_id FROM navigation_cursor WHERE id = 1").Scan(&historyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error getting navigation cursor: %w", err)
+	}
+	return historyID, nil
+}
+
+// Record a visit to a branch, like a browser does on following a link:
+// the entries ahead of the cursor are dropped and the new entry becomes the cursor
+func (r *NavigationRepository) Push(branchName string) error {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	cursor, err := getNavigationCursor(tx)
+	if err != nil {
+		return err
+	}
+
+	// Checking out the branch we are already on is not a new visit
+	var currentBranch string
+	err = tx.QueryRow("SELECT branch_name FROM navigation_history WHERE id = ?", cursor).Scan(&currentBranch)
+	if err == nil && currentBranch == branchName {
+		return tx.Commit()
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error getting navigation entry: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM navigation_history WHERE id > ?", cursor)
+	if err != nil {
+		return fmt.Errorf("error dropping forward history: %w", err)
+	}
+
+	result, err := tx.Exec("INSERT INTO navigation_history (branch_name, visited_at) VALUES (?, ?)", branchName, time.Now())
+	if err != nil {
+		return fmt.Errorf("error inserting navigation entry: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting last insert ID: %w", err)
+	}
+
+	_, err = tx.Exec("INSERT OR REPLACE INTO navigation_cursor (id, history_id) VALUES (1, ?)", id)
+	if err != nil {
+		return fmt.Errorf("error updating navigation cursor: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM navigation_history
+		WHERE id NOT IN (SELECT id FROM navigation_history ORDER BY id DESC LIMIT ?)
+	`, MaxNavigationEntries)
+	if err != nil {
+		return fmt.Errorf("error trimming navigation history: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Get the entry up to the given number of steps behind (or ahead of) the cursor.
+// It returns the entry along with the number of steps actually available,
+// and a nil entry if there is nothing in that direction.
+func (r *NavigationRepository) Peek(steps int, forward bool) (*NavigationEntry, int, error) {
+	cursor, err := getNavigationCursor(r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, branch_name, visited_at FROM navigation_history WHERE id < ? ORDER BY id DESC LIMIT ?"
+	if forward {
+		query = "SELECT id, branch_name, visited_at FROM navigation_history WHERE id > ? ORDER BY id ASC LIMIT ?"
+	}
+
+	rows, err := r.db.Query(query, cursor, steps)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying navigation history: %w", err)
+	}
+	defer rows.Close()
+
+	var entry *NavigationEntry
+	available := 0
+	for rows.Next() {
+		var e NavigationEntry
+		if err := rows.Scan(&e.ID, &e.BranchName, &e.VisitedAt); err != nil {
+			return nil, 0, fmt.Errorf("error scanning row: %w", err)
+		}
+		entry = &e
+		available++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return entry, available, nil
+}
+
+// Get the id of the history entry the cursor points to, 0 if there is no history
+func (r *NavigationRepository) GetCursor() (int64, error) {
+	return getNavigationCursor(r.db)
+}
+
+// Move the cursor to the given history entry
+func (r *NavigationRepository) SetCursor(historyID int64) error {
+	_, err := r.db.Exec("INSERT OR REPLACE INTO navigation_cursor (id, history_id) VALUES (1, ?)", historyID)
+	if err != nil {
+		return fmt.Errorf("error updating navigation cursor: %w", err)
+	}
+	return nil
+}
+
+// List the navigation history, oldest first, along with the id of the entry the cursor points to
+func (r *NavigationRepository) List() ([]NavigationEntry, int64, error) {
+	cursor, err := getNavigationCursor(r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query("SELECT id, branch_name, visited_at FROM navigation_history ORDER BY id ASC")
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying navigation history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []NavigationEntry
+	for rows.Next() {
+		var e NavigationEntry
+		if err := rows.Scan(&e.ID, &e.BranchName, &e.VisitedAt); err != nil {
+			return nil, 0, fmt.Errorf("error scanning row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return entries, cursor, nil
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// States of an operation of the undo log
+const (
+	OperationDone    = "done"    // Applied, undone next by gitbm undo
+	OperationUndone  = "undone"  // Undone, applied again by gitbm redo
+	OperationSkipped = "skipped" // Left out of undo and redo, as it could no longer be undone
+)
+
+// Number of operations kept in the undo log, the oldest ones are removed past it
+const MaxOperations = 1000
+
+// An entry of the undo log: a command that changed bookmarks, groups or checkout stats
+type Operation struct {
+	ID          int64
+	Command     string // Command that made the change, eg. delete
+	Description string // What changed, eg. Delete bookmark group old-feature
+	Steps       string // The changes, as JSON, to apply them in both directions
+	State       string // One of the Operation constants
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+const operationColumns = "id, command, description, steps, state, created_at, updated_at"
+
+type OperationRepository struct {
+	db Querier
+}
+
+func NewOperationRepository(db Querier) *OperationRepository {
+	return &OperationRepository{db: db}
+}
+
+// Record an operation. Like in an editor, the undone operations can no longer be redone
+// once something else changed, so they are removed.
+func (r *OperationRepository) Create(op *Operation) (err error) {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("DELETE FROM operations WHERE state = ?", OperationUndone); err != nil {
+		return fmt.Errorf("error removing undone operations: %w", err)
+	}
+
+	now := time.Now()
+	op.State = OperationDone
+	result, err := tx.Exec(
+		"INSERT INTO operations (command, description, steps, state, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		op.Command, op.Description, op.Steps, op.State, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording operation: %w", err)
+	}
+	op.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	op.CreatedAt = now
+	op.UpdatedAt = now
+
+	_, err = tx.Exec("DELETE FROM operations WHERE id <= ?", op.ID-MaxOperations)
+	if err != nil {
+		return fmt.Errorf("error removing old operations: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// List the most recent operations, newest first
+func (r *OperationRepository) List(limit int) ([]Operation, error) {
+	rows, err := r.db.Query("SELECT "+operationColumns+" FROM operations ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying operations: %w", err)
+	}
+	defer rows.Close()
+
+	var operations []Operation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, *op)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return operations, nil
+}
+
+// Get the operation undone next: the most recent one applied. It is nil if there is none.
+func (r *OperationRepository) GetLastDone() (*Operation, error) {
+	return r.get("SELECT "+operationColumns+" FROM operations WHERE state = ? ORDER BY id DESC LIMIT 1", OperationDone)
+}
+
+// Get the operation redone next: the most recently undone one. It is nil if there is none.
+func (r *OperationRepository) GetFirstUndone() (*Operation, error) {
+	return r.get("SELECT "+operationColumns+" FROM operations WHERE state = ? ORDER BY id ASC LIMIT 1", OperationUndone)
+}
+
+func (r *OperationRepository) get(query string, args ...interface{}) (*Operation, error) {
+	op, err := scanOperation(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return op, err
+}
+
+// Change the state of an operation, eg. once undone
+func (r *OperationRepository) SetState(id int64, state string) error {
+	_, err := r.db.Exec("UPDATE operations SET state = ?, updated_at = ? WHERE id = ?", state, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("error updating operation: %w", err)
+	}
+	return nil
+}
+
+func scanOperation(row rowScanner) (*Operation, error) {
+	var op Operation
+	err := row.Scan(&op.ID, &op.Command, &op.Description, &op.Steps, &op.State, &op.CreatedAt, &op.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning operation: %w", err)
+	}
+	return &op, nil
+}
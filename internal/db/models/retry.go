@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Attempts of a write failing because the database is busy, see RetryOnBusy
+const busyAttempts = 5
+
+// Run a write, retrying it with a growing, jittered, delay if it fails because the database is busy.
+// The busy timeout of the connection covers most conflicts, but not all: the write lock may be
+// held for longer (eg. by a long import), or a WAL snapshot read before the write may be stale.
+// The write is run again from the start, so it should be a whole transaction.
+func RetryOnBusy(write func() error) error {
+	delay := 20 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt == busyAttempts || !isBusy(err) {
+			return err
+		}
+
+		time.Sleep(delay + time.Duration(rand.Int63n(int64(delay))))
+		delay *= 2
+	}
+}
+
+// Check if an error is sqlite failing to get a lock
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
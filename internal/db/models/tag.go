@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Tag struct {
+	ID    int64
+	Name  string
+	Count int64 // Number of bookmarked branches with this tag
+}
+
+type TagRepository struct {
+	db Querier
+}
+
+func NewTagRepository(db Querier) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// Add a tag to a bookmarked branch, creating the tag if it does not exist yet
+func (r *TagRepository) AddToBranch(branchID int64, name string) error {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	_, err = tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", name)
+	if err != nil {
+		return fmt.Errorf("error creating tag: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO branch_tags (branch_id, tag_id)
+		SELECT ?, id FROM tags WHERE name = ?
+	`, branchID, name)
+	if err != nil {
+		return fmt.Errorf("error tagging branch: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Remove a tag from a bookmarked branch, dropping the tag once nothing uses it
+func (r *TagRepository) RemoveFromBranch(branchID int64, name string) error {
+	tx, err := begin(r.db)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	_, err = tx.Exec(`
+		DELETE FROM branch_tags
+		WHERE branch_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)
+	`, branchID, name)
+	if err != nil {
+		return fmt.Errorf("error untagging branch: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM branch_tags)")
+	if err != nil {
+		return fmt.Errorf("error removing unused tags: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// List all tags along with the number of bookmarked branches using them
+func (r *TagRepository) List() ([]Tag, error) {
+	query := `
+		SELECT t.id, t.name, COUNT(bt.branch_id)
+		FROM tags t
+		LEFT JOIN branch_tags bt ON bt.tag_id = t.id
+		GROUP BY t.id
+		ORDER BY t.name
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Count); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return tags, nil
+}
+
+// Fill in the tags of the given branches
+func (r *TagRepository) LoadForBranches(branches []Branch) error {
+	query := `
+		SELECT bt.branch_id, t.name
+		FROM branch_tags bt
+		JOIN tags t ON t.id = bt.tag_id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return fmt.Errorf("error querying branch tags: %w", err)
+	}
+	defer rows.Close()
+
+	tagsByBranch := make(map[int64][]string)
+	for rows.Next() {
+		var branchID int64
+		var name string
+		if err := rows.Scan(&branchID, &name); err != nil {
+			return fmt.Errorf("error scanning row: %w", err)
+		}
+		tagsByBranch[branchID] = append(tagsByBranch[branchID], name)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for i := range branches {
+		tags := tagsByBranch[branches[i].ID]
+		sort.Strings(tags)
+		branches[i].Tags = tags
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// What the repositories run their queries on, ie. *sql.DB or *sql.Tx. Repositories created on
+// a transaction run in it, so that several changes are committed, or rolled back, together.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// A transaction of a repository method, either its own or the one the repository was created on
+type txn struct {
+	*sql.Tx
+	joined bool // Whether the transaction belongs to the caller, which commits or rolls it back
+}
+
+// Begin a transaction on q, or join q if it is a transaction already
+func begin(q Querier) (*txn, error) {
+	switch q := q.(type) {
+	case *sql.Tx:
+		return &txn{Tx: q, joined: true}, nil
+	case *sql.DB:
+		tx, err := q.Begin()
+		if err != nil {
+			return nil, err
+		}
+		return &txn{Tx: tx}, nil
+	default:
+		panic("models: unsupported querier")
+	}
+}
+
+// Commit the transaction, unless it belongs to the caller
+func (t *txn) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Roll back the transaction, unless it belongs to the caller, which is left to roll it back
+func (t *txn) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
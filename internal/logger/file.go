@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Size over which a log file is rotated: it is renamed to <path>.1, replacing the previous one
+const MaxLogFileSize = 1 << 20
+
+// A structured log file, one JSON record per line
+type FileLogger struct {
+	*slog.Logger
+	file *os.File
+}
+
+// A record read back from a log file
+type Record struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Attrs   map[string]any // The other fields of the record, eg. the branch being tracked
+}
+
+// Open a log file for appending, rotating it first if it grew over MaxLogFileSize
+func OpenLogFile(path string) (*FileLogger, error) {
+	if info, err := os.Stat(path); err == nil && info.Size() > MaxLogFileSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, fmt.Errorf("error rotating log file: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file: %w", err)
+	}
+
+	return &FileLogger{Logger: slog.New(slog.NewJSONHandler(file, nil)), file: file}, nil
+}
+
+// Close the log file
+func (l *FileLogger) Close() error {
+	return l.file.Close()
+}
+
+// Read the records of a log file and of its rotated part, oldest first.
+// Lines which are not records, eg. written by an older version, are skipped.
+func ReadLogFile(path string) ([]Record, error) {
+	var records []Record
+	for _, p := range []string{path + ".1", path} {
+		file, err := os.Open(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading log file: %w", err)
+		}
+
+		read, err := readRecords(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading log file: %w", err)
+		}
+		records = append(records, read...)
+	}
+	return records, nil
+}
+
+func readRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var fields map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			continue
+		}
+
+		record := Record{Attrs: fields}
+		if t, ok := fields[slog.TimeKey].(string); ok {
+			record.Time, _ = time.Parse(time.RFC3339Nano, t)
+		}
+		record.Level, _ = fields[slog.LevelKey].(string)
+		record.Message, _ = fields[slog.MessageKey].(string)
+		delete(fields, slog.TimeKey)
+		delete(fields, slog.LevelKey)
+		delete(fields, slog.MessageKey)
+
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
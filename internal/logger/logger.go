@@ -1,25 +1,74 @@
 package logger
 
 import (
+	"os"
+
 	"github.com/fatih/color"
 )
 
+// Levels of messages, from the most to the least important
+type Level int
+
+const (
+	LevelError   Level = iota // Only errors, with --quiet
+	LevelWarning              // Errors and warnings
+	LevelInfo                 // Errors, warnings, info and success messages (default)
+	LevelDebug                // Everything, with --verbose
+)
+
+var level = LevelInfo
+
+func init() {
+	// Honor https://no-color.org in every command. color also disables colors when
+	// the output is not a terminal, eg. in the log of gitbm watch.
+	if os.Getenv("NO_COLOR") != "" {
+		color.NoColor = true
+	}
+}
+
+// Set the level of the messages to print. Errors and plain messages are always printed.
+func SetLevel(l Level) {
+	level = l
+}
+
+// Get the level of the messages printed
+func GetLevel() Level {
+	return level
+}
+
 func PrintError(format string, a ...interface{}) {
 	color.New(color.FgRed, color.Bold).Printf(format+"\n", a...)
 }
 
 func PrintSuccess(format string, a ...interface{}) {
+	if level < LevelInfo {
+		return
+	}
 	color.New(color.FgGreen, color.Bold).Printf(format+"\n", a...)
 }
 
 func PrintInfo(format string, a ...interface{}) {
+	if level < LevelInfo {
+		return
+	}
 	color.New(color.FgBlue, color.Bold).Printf(format+"\n", a...)
 }
 
 func PrintWarning(format string, a ...interface{}) {
+	if level < LevelWarning {
+		return
+	}
 	color.New(color.FgYellow, color.Bold).Printf(format+"\n", a...)
 }
 
+func PrintDebug(format string, a ...interface{}) {
+	if level < LevelDebug {
+		return
+	}
+	color.New(color.Faint).Printf(format+"\n", a...)
+}
+
+// Print a plain message, such as the output of a command. It is printed at every level.
 func Print(format string, a ...interface{}) {
 	color.New().Printf(format+"\n", a...)
 }
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package server
+
+import (
+	"net"
+	"syscall"
+)
+
+// Create the unix socket with no permissions for the group and others, so that no other
+// user can connect to it even for a moment. The umask is process wide, it is restored
+// right after.
+func listenPrivate(socketPath string) (net.Listener, error) {
+	umask := syscall.Umask(0177)
+	defer syscall.Umask(umask)
+	return net.Listen("unix", socketPath)
+}
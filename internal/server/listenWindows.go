@@ -0,0 +1,10 @@
+//go:build windows
+
+package server
+
+import "net"
+
+// Create the unix socket, whose access Windows restricts with the ACL of its directory
+func listenPrivate(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
@@ -0,0 +1,386 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/devadathanmb/gitbm/pkg/gitbm"
+)
+
+// How often new checkout events are looked for when streaming them
+const eventPollInterval = 500 * time.Millisecond
+
+// Local HTTP/JSON API over the gitbm client of a repository, for editor and tool integrations
+type Server struct {
+	client *gitbm.Client
+	mux    *http.ServeMux
+}
+
+func New(client *gitbm.Client) *Server {
+	s := &Server{client: client, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /groups", s.listGroups)
+	s.mux.HandleFunc("PUT /groups/current", s.switchGroup)
+	s.mux.HandleFunc("GET /bookmarks", s.listBookmarks)
+	s.mux.HandleFunc("GET /history/recent", s.listRecent)
+	s.mux.HandleFunc("GET /history/frequent", s.listFrequent)
+	s.mux.HandleFunc("GET /history/navigation", s.listNavigation)
+	s.mux.HandleFunc("POST /checkout", s.checkout)
+	s.mux.HandleFunc("POST /jump", s.jump)
+	s.mux.HandleFunc("GET /events", s.streamEvents)
+	return s
+}
+
+// Listen on a unix socket that only the current user can connect to.
+// A socket file left behind by a server that is no longer running is replaced.
+func Listen(socketPath string) (net.Listener, error) {
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a server is already listening on %s", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("error removing stale socket: %w", err)
+		}
+	}
+
+	listener, err := listenPrivate(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %w", socketPath, err)
+	}
+	return listener, nil
+}
+
+// Serve requests until the listener is closed
+func (s *Server) Serve(listener net.Listener) error {
+	err := http.Serve(listener, s.mux)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+type groupJSON struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	RuleType string `json:"rule_type,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Current  bool   `json:"current"`
+}
+
+type bookmarkJSON struct {
+	Group   string     `json:"group"`
+	Name    string     `json:"name"`
+	Alias   string     `json:"alias,omitempty"`
+	Notes   string     `json:"notes,omitempty"`
+	Remote  string     `json:"remote,omitempty"`
+	Kind    string     `json:"kind"` // branch, tag, commit or ref
+	Tags    []string   `json:"tags"`
+	Tickets []string   `json:"tickets"`
+	AddedAt *time.Time `json:"added_at,omitempty"` // Branches of smart groups are not added explicitly
+}
+
+type checkoutJSON struct {
+	Name             string    `json:"name"`
+	CheckoutCount    int64     `json:"checkout_count"`
+	LastCheckedOutAt time.Time `json:"last_checked_out_at"`
+	LatestCommitMsg  string    `json:"latest_commit_msg"`
+}
+
+type eventJSON struct {
+	ID           int64     `json:"id"`
+	Branch       string    `json:"branch"`
+	CheckedOutAt time.Time `json:"checked_out_at"`
+	Source       string    `json:"source"`
+}
+
+type errorJSON struct {
+	Error    string   `json:"error"`
+	Remedies []string `json:"remedies,omitempty"` // How to fix known git failures
+}
+
+// GET /groups: all bookmark groups
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	var currentID int64
+	current, err := s.client.CurrentGroup()
+	if err != nil && !errors.Is(err, gitbm.ErrNoCurrentGroup) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if current != nil {
+		currentID = current.ID
+	}
+
+	groups, err := s.client.Groups()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	output := make([]groupJSON, 0, len(groups))
+	for _, g := range groups {
+		output = append(output, groupJSON{ID: g.ID, Name: g.Name, RuleType: g.RuleType, Rule: g.Rule, Current: g.ID == currentID})
+	}
+	writeJSON(w, output)
+}
+
+// PUT /groups/current {"name": "..."}: switch the current bookmark group
+func (s *Server) switchGroup(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	group, err := s.client.SwitchGroup(body.Name)
+	if err != nil {
+		writeError(w, statusOf(err, http.StatusInternalServerError), err)
+		return
+	}
+	writeJSON(w, groupJSON{ID: group.ID, Name: group.Name, RuleType: group.RuleType, Rule: group.Rule, Current: true})
+}
+
+// GET /bookmarks[?group=<name>|?all=true]: bookmarks of the current group, of the given group or of all groups
+func (s *Server) listBookmarks(w http.ResponseWriter, r *http.Request) {
+	groupNames, err := s.client.GroupNames()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var branches []gitbm.Bookmark
+	if r.URL.Query().Get("all") == "true" {
+		branches, err = s.client.AllBookmarks()
+		var smartGroupErr *gitbm.SmartGroupError
+		if err != nil && !errors.As(err, &smartGroupErr) {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		branches, err = s.client.Bookmarks(r.URL.Query().Get("group"))
+		if err != nil {
+			writeError(w, statusOf(err, http.StatusInternalServerError), err)
+			return
+		}
+	}
+
+	// Tickets are only informative, so a broken tracker config does not fail the request
+	s.client.LoadTickets(branches)
+
+	output := make([]bookmarkJSON, 0, len(branches))
+	for _, b := range branches {
+		tags := b.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		tickets := b.Tickets
+		if tickets == nil {
+			tickets = []string{}
+		}
+		var addedAt *time.Time
+		if !b.CreatedAt.IsZero() {
+			addedAt = &b.CreatedAt
+		}
+		output = append(output, bookmarkJSON{
+			Group:   groupNames[b.BookmarkGroupID],
+			Name:    b.Name,
+			Alias:   b.Alias,
+			Notes:   b.Notes,
+			Remote:  b.Remote,
+			Kind:    b.Kind,
+			Tags:    tags,
+			Tickets: tickets,
+			AddedAt: addedAt,
+		})
+	}
+	writeJSON(w, output)
+}
+
+// GET /history/recent[?limit=N]: most recently checked out branches
+func (s *Server) listRecent(w http.ResponseWriter, r *http.Request) {
+	s.listCheckouts(w, r, s.client.RecentBranches)
+}
+
+// GET /history/frequent[?limit=N]: most frequently checked out branches
+func (s *Server) listFrequent(w http.ResponseWriter, r *http.Request) {
+	s.listCheckouts(w, r, s.client.FrequentBranches)
+}
+
+func (s *Server) listCheckouts(w http.ResponseWriter, r *http.Request, list func(int, bool) ([]gitbm.BranchStats, error)) {
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit '%s'", value))
+			return
+		}
+	}
+
+	checkouts, err := list(limit, false)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	output := make([]checkoutJSON, 0, len(checkouts))
+	for _, c := range checkouts {
+		output = append(output, checkoutJSON{Name: c.Name, CheckoutCount: c.CheckoutCount, LastCheckedOutAt: c.LastCheckedOutAt, LatestCommitMsg: c.LatestCommitMsg})
+	}
+	writeJSON(w, output)
+}
+
+// GET /history/navigation: the back/forward navigation history, oldest first
+func (s *Server) listNavigation(w http.ResponseWriter, r *http.Request) {
+	entries, cursor, err := s.client.NavigationHistory()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	type entryJSON struct {
+		ID        int64     `json:"id"`
+		Branch    string    `json:"branch"`
+		VisitedAt time.Time `json:"visited_at"`
+		Current   bool      `json:"current"`
+	}
+	output := make([]entryJSON, 0, len(entries))
+	for _, e := range entries {
+		output = append(output, entryJSON{ID: e.ID, Branch: e.BranchName, VisitedAt: e.VisitedAt, Current: e.ID == cursor})
+	}
+	writeJSON(w, output)
+}
+
+// POST /checkout {"branch": "...", "group": "..."}: checkout a bookmark of the given or current group
+func (s *Server) checkout(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Branch string `json:"branch"`
+		Group  string `json:"group"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	tracked, err := s.client.Checkout(body.Group, body.Branch)
+	if err != nil {
+		writeError(w, statusOf(err, http.StatusConflict), err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"branch": body.Branch, "created_tracking_branch": tracked})
+}
+
+// POST /jump {"steps": N, "direction": "back"|"forward"}: move through the navigation history
+func (s *Server) jump(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Steps     int    `json:"steps"`
+		Direction string `json:"direction"`
+	}{Steps: 1, Direction: "back"}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if body.Steps < 1 || (body.Direction != "back" && body.Direction != "forward") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("steps must be positive and direction back or forward"))
+		return
+	}
+
+	entry, steps, err := s.client.Jump(body.Steps, body.Direction == "forward")
+	if errors.Is(err, gitbm.ErrNoHistory) {
+		writeError(w, http.StatusConflict, fmt.Errorf("nothing to go %s to", body.Direction))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"branch": entry.BranchName, "steps": steps})
+}
+
+// GET /events[?after=<id>]: stream checkout events as newline-delimited JSON as the hook records them.
+// Only events recorded after the request are sent, unless an event ID to start after is given.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	var lastID int64
+	var err error
+	if value := r.URL.Query().Get("after"); value != "" {
+		lastID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid event id '%s'", value))
+			return
+		}
+	} else {
+		lastID, err = s.client.LastCheckoutEventID()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		events, err := s.client.CheckoutEventsAfter(lastID)
+		if err != nil {
+			// The hook may be holding the database, try again on the next tick
+			continue
+		}
+
+		for _, e := range events {
+			if err := encoder.Encode(eventJSON{ID: e.ID, Branch: e.BranchName, CheckedOutAt: e.CheckedOutAt, Source: e.Source}); err != nil {
+				return
+			}
+			lastID = e.ID
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+	}
+}
+
+// HTTP status to respond with for an error of the client, fallback if it is not a known error
+func statusOf(err error, fallback int) int {
+	switch {
+	case errors.Is(err, gitbm.ErrGroupNotFound), errors.Is(err, gitbm.ErrBookmarkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, gitbm.ErrNoCurrentGroup):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorJSON{Error: err.Error(), Remedies: gitbm.Remedies(err)})
+}
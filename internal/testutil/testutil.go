@@ -0,0 +1,29 @@
+package testutil
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// Create a git repository with one commit on main, and the given branches pointing at it.
+// It is removed at the end of the test.
+func NewRepo(t testing.TB, branches ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	commands := [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=gitbm", "-c", "user.email=gitbm@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+	}
+	for _, branch := range branches {
+		commands = append(commands, []string{"branch", branch})
+	}
+
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	return dir
+}
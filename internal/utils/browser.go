@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Opens a URL in $BROWSER, falling back to the opener of the platform (xdg-open on Linux)
+func OpenURL(url string) error {
+	// The browser may come with its own arguments, eg. "firefox --new-tab"
+	opener := strings.Fields(os.Getenv("BROWSER"))
+	if len(opener) == 0 {
+		switch runtime.GOOS {
+		case "darwin":
+			opener = []string{"open"}
+		case "windows":
+			opener = []string{"rundll32", "url.dll,FileProtocolHandler"}
+		default:
+			opener = []string{"xdg-open"}
+		}
+	}
+
+	// Terminal browsers need the terminal, graphical ones return right away
+	cmd := exec.Command(opener[0], append(opener[1:], url)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error opening %s with %s: %w", url, opener[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Gets the user's preferred editor, falling back to vi when neither is set or both are blank
+func getEditor() string {
+	if editor := strings.TrimSpace(os.Getenv("VISUAL")); editor != "" {
+		return editor
+	}
+	if editor := strings.TrimSpace(os.Getenv("EDITOR")); editor != "" {
+		return editor
+	}
+	return "vi"
+}
+
+// Opens the given text in the user's editor and returns the edited text
+func EditText(initial string) (string, error) {
+	file, err := os.CreateTemp("", "gitbm-*.md")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(initial); err != nil {
+		file.Close()
+		return "", fmt.Errorf("error writing temporary file: %w", err)
+	}
+	file.Close()
+
+	// The editor may come with its own arguments, eg. "code --wait"
+	editorArgs := strings.Fields(getEditor())
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("error reading temporary file: %w", err)
+	}
+
+	return strings.TrimSpace(string(edited)), nil
+}
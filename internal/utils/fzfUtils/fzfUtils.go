@@ -9,22 +9,85 @@ import (
 // ErrSelectionCancelled is returned when the user cancels the fuzzy selection.
 var ErrSelectionCancelled = fmt.Errorf("selection cancelled")
 
-// FuzzyFind presents a list of items to the user for fuzzy selection.
+// Picker lets the user pick one of items interactively, returning its index.
+// It returns ErrSelectionCancelled if the user cancels the selection.
+type Picker interface {
+	Pick(items []string, prompt string, opts Options) (int, error)
+}
+
+// Options of a selection
+type Options struct {
+	Preview func(i int) string // Renders the preview window of the highlighted item, if set
+	Query   string             // Pre-filled query
+}
+
+// Option configures the fuzzy finder.
+type Option func(*Options)
+
+// WithPreview shows a preview window rendered by previewFunc for the highlighted item.
+func WithPreview[T any](items []T, previewFunc func(T) string) Option {
+	return func(o *Options) {
+		o.Preview = func(i int) string {
+			if i < 0 || i >= len(items) {
+				return ""
+			}
+			return previewFunc(items[i])
+		}
+	}
+}
+
+// WithQuery pre-fills the query of the fuzzy finder.
+func WithQuery(query string) Option {
+	return func(o *Options) {
+		o.Query = query
+	}
+}
+
+// Fzf is the Picker of the terminal, a fuzzy finder like fzf.
+type Fzf struct{}
+
+func (Fzf) Pick(items []string, prompt string, opts Options) (int, error) {
+	fzfOpts := []fuzzyfinder.Option{fuzzyfinder.WithPromptString(prompt + " : ")}
+	if opts.Preview != nil {
+		fzfOpts = append(fzfOpts, fuzzyfinder.WithPreviewWindow(func(i, width, height int) string {
+			return opts.Preview(i)
+		}))
+	}
+	if opts.Query != "" {
+		fzfOpts = append(fzfOpts, fuzzyfinder.WithQuery(opts.Query))
+	}
+
+	idx, err := fuzzyfinder.Find(items, func(i int) string { return items[i] }, fzfOpts...)
+	if err == fuzzyfinder.ErrAbort {
+		return 0, ErrSelectionCancelled
+	}
+	return idx, err
+}
+
+// FuzzyFind presents a list of items to the user for fuzzy selection with picker, Fzf if nil.
 // It returns the selected item and any error encountered.
-func FuzzyFind[T any](items []T, displayFunc func(T) string, promptString string) (T, error) {
+func FuzzyFind[T any](picker Picker, items []T, displayFunc func(T) string, promptString string, opts ...Option) (T, error) {
 	var zero T
-	idx, err := fuzzyfinder.Find(
-		items,
-		func(i int) string {
-			return displayFunc(items[i])
-		},
-		fuzzyfinder.WithPromptString(promptString+" : "),
-	)
+	if picker == nil {
+		picker = Fzf{}
+	}
+
+	var options Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	display := make([]string, len(items))
+	for i, item := range items {
+		display[i] = displayFunc(item)
+	}
+
+	idx, err := picker.Pick(display, promptString, options)
 	if err != nil {
-		if err == fuzzyfinder.ErrAbort {
-			return zero, ErrSelectionCancelled
-		}
 		return zero, err
 	}
+	if idx < 0 || idx >= len(items) {
+		return zero, fmt.Errorf("invalid selection %d of %d items", idx, len(items))
+	}
 	return items[idx], nil
 }
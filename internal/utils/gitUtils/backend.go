@@ -0,0 +1,76 @@
+package gitutils
+
+import (
+	"path"
+	"strings"
+)
+
+// Git config key selecting the backend used to read the repository
+const BackendConfigKey = "gitbm.gitBackend"
+
+// Values of the gitbm.gitBackend git config
+const (
+	BackendExec  = "exec"   // Run git for every read (default)
+	BackendGoGit = "go-git" // Read refs and commits in process with go-git
+)
+
+// The read-heavy operations on a repository, that can be done without running git.
+// Operations that change the repository or run hooks (checkout, branch creation, ...) always run git.
+type Backend interface {
+	// Name of the checked out branch, HEAD if detached
+	CurrentBranch() (string, error)
+	// Name of the previously checked out branch, ie. what 'git checkout -' would switch to
+	PreviousBranch() (string, error)
+	// Check if a ref (branch, tag, commit, ...) resolves to a commit
+	RefExists(ref string) bool
+	// Resolve a ref to the object name of the commit it points to
+	ResolveRef(ref string) (string, error)
+	// Subject of the commit a revision points to
+	CommitSubject(rev string) (string, error)
+	// List local branches sorted by name, matching one of the patterns (all local branches by default)
+	ListBranches(patterns ...string) ([]BranchRef, error)
+	// List the local branches that are merged into target, or not merged if merged is false
+	ListMergedBranches(target string, merged bool) ([]BranchRef, error)
+	// List the remote-tracking branches of all remotes, without the symbolic <remote>/HEAD refs
+	ListRemoteBranches() ([]RemoteBranchRef, error)
+}
+
+// Select the backend of the repository from the gitbm.gitBackend git config.
+// The go-git backend falls back to running git if it can not open the repository,
+// eg. because it uses a repository format go-git does not support.
+func (r *Repo) selectBackend() Backend {
+	exec := &execBackend{repo: r}
+
+	name, _ := r.GetGitConfig(BackendConfigKey)
+	if name != BackendGoGit {
+		return exec
+	}
+
+	goGit, err := newGoGitBackend(r.Dir)
+	if err != nil {
+		return exec
+	}
+	return goGit
+}
+
+// Match a full ref name like git for-each-ref does: with fnmatch if the pattern
+// has wildcards, otherwise literally or as a prefix up to a slash
+func matchRefPattern(refName string, pattern string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, _ := path.Match(pattern, refName)
+		return matched
+	}
+
+	pattern = strings.TrimSuffix(pattern, "/")
+	return refName == pattern || strings.HasPrefix(refName, pattern+"/")
+}
+
+// Subject of a commit message as git shows it: its first paragraph on one line
+func commitSubject(message string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+	lines := strings.Split(strings.TrimRight(paragraph, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, " ")
+}
@@ -0,0 +1,247 @@
+package gitutils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The go-git backend must read the repository like git does
+func TestBackendsAgree(t *testing.T) {
+	dir := t.TempDir()
+	git := func(date string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	// main has 3 commits, merged points at the second one, and the other branches fork from
+	// the first one, with a commit older than any of main and one newer than all of them
+	git("2024-01-01T00:00:00", "init", "-q", "-b", "main")
+	git("2024-01-01T00:00:00", "commit", "-q", "--allow-empty", "-m", "First")
+	git("2024-01-02T00:00:00", "commit", "-q", "--allow-empty", "-m", "Second")
+	git("2024-01-02T00:00:00", "branch", "merged")
+	git("2024-01-03T00:00:00", "commit", "-q", "--allow-empty", "-m", "Third")
+	git("2020-01-01T00:00:00", "checkout", "-q", "-b", "old", "main~2")
+	git("2020-01-01T00:00:00", "commit", "-q", "--allow-empty", "-m", "Old")
+	git("2025-01-01T00:00:00", "checkout", "-q", "-b", "new", "main~2")
+	git("2025-01-01T00:00:00", "commit", "-q", "--allow-empty", "-m", "New")
+	git("2025-01-01T00:00:00", "checkout", "-q", "main")
+
+	goGit, err := newGoGitBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitExec := &execBackend{repo: NewRepo(dir)}
+
+	names := func(refs []BranchRef) []string {
+		var names []string
+		for _, ref := range refs {
+			names = append(names, ref.Name)
+		}
+		return names
+	}
+	for _, merged := range []bool{true, false} {
+		want, err := gitExec.ListMergedBranches("main", merged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := goGit.ListMergedBranches("main", merged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names(got), names(want)) {
+			t.Errorf("ListMergedBranches(main, %v) = %v, want %v", merged, names(got), names(want))
+		}
+	}
+
+	for _, rev := range []string{"main", "old", "merged~1"} {
+		want, err := gitExec.CommitSubject(rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := goGit.CommitSubject(rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("CommitSubject(%s) = %s, want %s", rev, got, want)
+		}
+	}
+
+	want, err := gitExec.PreviousBranch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := goGit.PreviousBranch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("PreviousBranch() = %s, want %s", got, want)
+	}
+}
+
+// Size of the repository the backends are benchmarked on, like a monorepo with years of branches
+const (
+	benchCommits        = 100
+	benchBranches       = 5000
+	benchRemoteBranches = 1000
+)
+
+// Benchmark every method of the exec and go-git backends on a repository with thousands of refs,
+// eg. go test -bench Backend ./internal/utils/gitUtils. scripts/bench-git-backend.sh compares
+// them through the gitbm commands instead.
+func BenchmarkBackend(b *testing.B) {
+	dir := newBenchRepo(b)
+
+	goGit, err := newGoGitBackend(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	backends := []struct {
+		name    string
+		backend Backend
+	}{
+		{BackendExec, &execBackend{repo: NewRepo(dir)}},
+		{BackendGoGit, goGit},
+	}
+
+	for _, bb := range backends {
+		backend := bb.backend
+		b.Run(bb.name+"/CurrentBranch", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := backend.CurrentBranch(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bb.name+"/PreviousBranch", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := backend.PreviousBranch(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bb.name+"/RefExists", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if !backend.RefExists(benchBranch(i)) {
+					b.Fatalf("%s does not exist", benchBranch(i))
+				}
+			}
+		})
+		b.Run(bb.name+"/RefExists/missing", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if backend.RefExists("missing/branch") {
+					b.Fatal("missing/branch exists")
+				}
+			}
+		})
+		b.Run(bb.name+"/ResolveRef", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := backend.ResolveRef(benchBranch(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bb.name+"/CommitSubject", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := backend.CommitSubject(benchBranch(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bb.name+"/ListBranches", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchListBranches(b, benchBranches+1, func() ([]BranchRef, error) {
+					return backend.ListBranches()
+				})
+			}
+		})
+		b.Run(bb.name+"/ListBranches/pattern", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchListBranches(b, 1111, func() ([]BranchRef, error) {
+					return backend.ListBranches("refs/heads/feature/JIRA-1*")
+				})
+			}
+		})
+		b.Run(bb.name+"/ListMergedBranches", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchListBranches(b, benchBranches+1, func() ([]BranchRef, error) {
+					return backend.ListMergedBranches("main", true)
+				})
+			}
+		})
+		b.Run(bb.name+"/ListRemoteBranches", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				refs, err := backend.ListRemoteBranches()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(refs) != benchRemoteBranches {
+					b.Fatalf("%d remote branches, want %d", len(refs), benchRemoteBranches)
+				}
+			}
+		})
+	}
+}
+
+// List branches, failing unless there are as many as expected, so that the backends
+// are compared on the same result
+func benchListBranches(b *testing.B, want int, list func() ([]BranchRef, error)) {
+	b.Helper()
+	refs, err := list()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(refs) != want {
+		b.Fatalf("%d branches, want %d", len(refs), want)
+	}
+}
+
+func benchBranch(i int) string {
+	return fmt.Sprintf("feature/JIRA-%d", i%benchBranches+1)
+}
+
+// Create a repository with a linear history on main, and local and remote-tracking branches
+// pointing all over it, its refs packed as git gc does
+func newBenchRepo(b *testing.B) string {
+	b.Helper()
+	dir := b.TempDir()
+	git := func(stdin string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=bench", "-c", "user.email=bench@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Stdin = strings.NewReader(stdin)
+		output, err := cmd.Output()
+		if err != nil {
+			b.Fatalf("git %v failed: %v", args, err)
+		}
+		return string(output)
+	}
+
+	git("", "init", "-q", "-b", "main")
+	git("", "remote", "add", "origin", "https://example.com/repo.git")
+	for i := 1; i <= benchCommits; i++ {
+		git("", "commit", "-q", "--allow-empty", "-m", fmt.Sprintf("Commit %d", i))
+	}
+	commits := strings.Fields(git("", "rev-list", "main"))
+
+	var updates strings.Builder
+	for i := 1; i <= benchBranches; i++ {
+		fmt.Fprintf(&updates, "create refs/heads/feature/JIRA-%d %s\n", i, commits[i%len(commits)])
+	}
+	for i := 1; i <= benchRemoteBranches; i++ {
+		fmt.Fprintf(&updates, "create refs/remotes/origin/feature/JIRA-%d %s\n", i, commits[i%len(commits)])
+	}
+	git(updates.String(), "update-ref", "--stdin")
+	git("", "checkout", "-q", "feature/JIRA-1")
+	git("", "checkout", "-q", "main")
+	git("", "pack-refs", "--all")
+	return dir
+}
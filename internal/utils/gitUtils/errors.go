@@ -0,0 +1,223 @@
+package gitutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// A git command that failed, with what git printed on stderr
+type CommandError struct {
+	Args   []string // Arguments of the git command, eg. checkout main
+	Stderr string
+	Err    error // Error running the command, usually an *exec.ExitError
+}
+
+func (e *CommandError) Error() string {
+	if message := e.Message(); message != "" {
+		return message
+	}
+	return fmt.Sprintf("git %s: %v", strings.Join(e.Args, " "), e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// The first error git reported, without its "error: " or "fatal: " prefix
+func (e *CommandError) Message() string {
+	for _, line := range strings.Split(e.Stderr, "\n") {
+		for _, prefix := range []string{"error: ", "fatal: "} {
+			if message, ok := strings.CutPrefix(line, prefix); ok {
+				return message
+			}
+		}
+	}
+	return ""
+}
+
+// A git failure that comes with suggestions on how to fix it
+type ActionableError interface {
+	error
+	Remedies() []string
+}
+
+// Local changes, or untracked files, would be overwritten by the checkout
+type DirtyTreeError struct {
+	Command   *CommandError // The failed git command
+	Files     []string
+	Untracked bool // The files are untracked files, not local changes
+}
+
+func (e *DirtyTreeError) Error() string {
+	if e.Untracked {
+		return fmt.Sprintf("%d untracked file(s) would be overwritten", len(e.Files))
+	}
+	return fmt.Sprintf("your local changes to %d file(s) would be overwritten", len(e.Files))
+}
+
+func (e *DirtyTreeError) Unwrap() error {
+	return e.Command
+}
+
+func (e *DirtyTreeError) Remedies() []string {
+	if e.Untracked {
+		return []string{
+			"Move or delete the untracked files: " + strings.Join(e.Files, ", "),
+			"Or stash them along with your changes: git stash push --include-untracked",
+		}
+	}
+	return []string{
+		"Stash your changes, switch, then restore them: git stash push, then git stash pop",
+		"Or commit your changes first: git commit -a",
+		"Or discard them: git restore " + strings.Join(e.Files, " "),
+	}
+}
+
+// The branch, or ref, to check out does not exist
+type UnknownRefError struct {
+	Command *CommandError // The failed git command
+	Ref     string
+}
+
+func (e *UnknownRefError) Error() string {
+	return fmt.Sprintf("branch or ref '%s' does not exist", e.Ref)
+}
+
+func (e *UnknownRefError) Unwrap() error {
+	return e.Command
+}
+
+func (e *UnknownRefError) Remedies() []string {
+	return []string{
+		"Check the name of the branch: git branch --all",
+		"If it exists on a remote, fetch it first: git fetch",
+		"If it was deleted, remove its bookmark: gitbm remove " + e.Ref + ", or gitbm prune",
+	}
+}
+
+// The branch is already checked out in another worktree
+type WorktreeConflictError struct {
+	Command  *CommandError // The failed git command
+	Branch   string
+	Worktree string // Path of the worktree the branch is checked out in
+}
+
+func (e *WorktreeConflictError) Error() string {
+	return fmt.Sprintf("branch %s is already checked out in the worktree at %s", e.Branch, e.Worktree)
+}
+
+func (e *WorktreeConflictError) Unwrap() error {
+	return e.Command
+}
+
+func (e *WorktreeConflictError) Remedies() []string {
+	return []string{
+		"Work on it in that worktree: cd " + e.Worktree,
+		"Or switch that worktree to another branch: git -C " + e.Worktree + " switch --detach",
+	}
+}
+
+// The index is locked by another git process, or by one that crashed
+type IndexLockedError struct {
+	Command  *CommandError // The failed git command
+	LockFile string
+}
+
+func (e *IndexLockedError) Error() string {
+	return "the git index is locked by another git process"
+}
+
+func (e *IndexLockedError) Unwrap() error {
+	return e.Command
+}
+
+func (e *IndexLockedError) Remedies() []string {
+	return []string{
+		"Wait for the other git process (eg. your editor) to finish and retry",
+		"If no git process is running, one crashed, remove the lock file: rm " + e.LockFile,
+	}
+}
+
+var (
+	dirtyTreePattern   = regexp.MustCompile(`(?m)^error: Your local changes to the following files would be overwritten`)
+	untrackedPattern   = regexp.MustCompile(`(?m)^error: The following untracked working tree files would be overwritten`)
+	unknownRefPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`pathspec '(.+)' did not match any file\(s\) known to git`),
+		regexp.MustCompile(`invalid reference: (.+)`),
+		regexp.MustCompile(`'(.+)' is not a commit`),
+	}
+	worktreeConflictPattern = regexp.MustCompile(`'(.+)' is already (?:checked out|used by worktree) at '(.+)'`)
+	indexLockedPattern      = regexp.MustCompile(`Unable to create '(.+\.lock)': File exists`)
+)
+
+// Run a git command built with r.command, returning its stdout. On failure, the error
+// is one of the structured errors above if git's stderr is recognized, or a *CommandError.
+func (r *Repo) run(cmd *exec.Cmd) ([]byte, error) {
+	// The errors of git are recognized by their English messages, whatever the locale of the user
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "LC_ALL=C")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, classifyError(&CommandError{Args: cmd.Args[1:], Stderr: stderr.String(), Err: err})
+	}
+	return stdout.Bytes(), nil
+}
+
+// Turn a failed git command into a structured error when git's output is recognized
+func classifyError(err *CommandError) error {
+	if dirtyTreePattern.MatchString(err.Stderr) || untrackedPattern.MatchString(err.Stderr) {
+		return &DirtyTreeError{
+			Command:   err,
+			Files:     listedFiles(err.Stderr),
+			Untracked: untrackedPattern.MatchString(err.Stderr),
+		}
+	}
+
+	for _, pattern := range unknownRefPatterns {
+		if match := pattern.FindStringSubmatch(err.Stderr); match != nil {
+			return &UnknownRefError{Command: err, Ref: match[1]}
+		}
+	}
+
+	if match := worktreeConflictPattern.FindStringSubmatch(err.Stderr); match != nil {
+		return &WorktreeConflictError{Command: err, Branch: match[1], Worktree: match[2]}
+	}
+
+	if match := indexLockedPattern.FindStringSubmatch(err.Stderr); match != nil {
+		return &IndexLockedError{Command: err, LockFile: match[1]}
+	}
+
+	return err
+}
+
+// The files git lists, indented by a tab, below an error
+func listedFiles(stderr string) []string {
+	var files []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if file, ok := strings.CutPrefix(line, "\t"); ok {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Get the remedies of an error if it, or an error it wraps, is an ActionableError
+func Remedies(err error) []string {
+	var actionable ActionableError
+	if errors.As(err, &actionable) {
+		return actionable.Remedies()
+	}
+	return nil
+}
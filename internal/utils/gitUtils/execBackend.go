@@ -0,0 +1,144 @@
+package gitutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Backend running a git command for every read
+type execBackend struct {
+	repo *Repo
+}
+
+func (b *execBackend) CurrentBranch() (string, error) {
+	cmd := b.repo.command("rev-parse", "--abbrev-ref", "HEAD")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func (b *execBackend) PreviousBranch() (string, error) {
+	cmd := b.repo.command("rev-parse", "--abbrev-ref", "@{-1}")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func (b *execBackend) RefExists(ref string) bool {
+	cmd := b.repo.command("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return cmd.Run() == nil
+}
+
+func (b *execBackend) ResolveRef(ref string) (string, error) {
+	output, err := b.repo.command("rev-parse", "--verify", "--quiet", ref+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("error resolving '%s': %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (b *execBackend) CommitSubject(rev string) (string, error) {
+	output, err := b.repo.run(b.repo.command("log", "-1", "--format=%s", rev, "--"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (b *execBackend) ListBranches(patterns ...string) ([]BranchRef, error) {
+	return b.forEachBranch(nil, patterns)
+}
+
+func (b *execBackend) ListMergedBranches(target string, merged bool) ([]BranchRef, error) {
+	option := "--merged"
+	if !merged {
+		option = "--no-merged"
+	}
+	return b.forEachBranch([]string{option, target}, nil)
+}
+
+// List local branches using git for-each-ref.
+// The options (eg. --merged main) are passed as is, and the patterns default to all local branches.
+func (b *execBackend) forEachBranch(options []string, patterns []string) ([]BranchRef, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"refs/heads/"}
+	}
+
+	args := []string{"for-each-ref", "--format=%(refname:short)%00%(objectname)%00%(authorname)%00%(authoremail)%00%(subject)"}
+	args = append(args, options...)
+	args = append(args, patterns...)
+
+	cmd := b.repo.command(args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing branches: %w", err)
+	}
+
+	var branches []BranchRef
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\x00", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		branches = append(branches, BranchRef{
+			Name:        fields[0],
+			Commit:      fields[1],
+			AuthorName:  fields[2],
+			AuthorEmail: strings.Trim(fields[3], "<>"),
+			Subject:     fields[4],
+		})
+	}
+	return branches, nil
+}
+
+func (b *execBackend) ListRemoteBranches() ([]RemoteBranchRef, error) {
+	output, err := b.repo.command("remote").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing remotes: %w", err)
+	}
+	remotes := strings.Fields(string(output))
+
+	output, err = b.repo.command("for-each-ref", "--format=%(refname:lstrip=2)%00%(symref)", "refs/remotes/").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing remote branches: %w", err)
+	}
+
+	var branches []RemoteBranchRef
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		ref, symref, _ := strings.Cut(line, "\x00")
+		if ref == "" || symref != "" {
+			continue
+		}
+
+		remote := longestRemotePrefix(remotes, ref)
+		if remote == "" {
+			continue
+		}
+
+		branches = append(branches, RemoteBranchRef{Remote: remote, Name: strings.TrimPrefix(ref, remote+"/")})
+	}
+	return branches, nil
+}
+
+// Remote names may contain slashes too, so match the longest remote name prefixing ref (eg. origin/feature/x)
+func longestRemotePrefix(remotes []string, ref string) string {
+	var remote string
+	for _, r := range remotes {
+		if strings.HasPrefix(ref, r+"/") && len(r) > len(remote) {
+			remote = r
+		}
+	}
+	return remote
+}
@@ -4,38 +4,462 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// A git repository, whose git commands run in its working tree
+type Repo struct {
+	Dir string // Root of the working tree, the current directory if empty
+
+	backend     Backend
+	backendOnce sync.Once
+}
+
+func NewRepo(dir string) *Repo {
+	return &Repo{Dir: dir}
+}
+
+// Create a repository read by the given backend, instead of the one set by the gitbm.gitBackend git config
+func NewRepoWithBackend(dir string, backend Backend) *Repo {
+	r := &Repo{Dir: dir, backend: backend}
+	r.backendOnce.Do(func() {})
+	return r
+}
+
+// Build a git command running in the working tree of the repository
+func (r *Repo) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	return cmd
+}
+
+// Backend reading the repository, selected on first use by the gitbm.gitBackend git config
+func (r *Repo) Backend() Backend {
+	r.backendOnce.Do(func() {
+		r.backend = r.selectBackend()
+	})
+	return r.backend
+}
+
 // Get current git branch name
-func GetCurrentGitBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
+func (r *Repo) GetCurrentGitBranch() (string, error) {
+	return r.Backend().CurrentBranch()
+}
+
+// Get the name of the previously checked out branch, ie. what 'git checkout -' would switch to
+func (r *Repo) GetPreviousGitBranch() (string, error) {
+	return r.Backend().PreviousBranch()
+}
+
+// Environment variable set on checkouts done by gitbm back/forward,
+// so that the post-checkout hook does not record them in the navigation history
+const SkipNavigationEnv = "GITBM_SKIP_NAVIGATION"
+
+// Checkout a branch, the optional env entries (KEY=value) are passed on to git and its hooks
+func (r *Repo) GitCheckout(branchName string, env ...string) error {
+	cmd := r.command("checkout", branchName)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	_, err := r.run(cmd)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("error checking out %s: %w", branchName, err)
+	}
+	return nil
+}
+
+// Checkout a tag, commit or any other revision as a detached HEAD.
+// The optional env entries (KEY=value) are passed on to git and its hooks.
+func (r *Repo) CheckoutDetached(rev string, env ...string) error {
+	cmd := r.command("checkout", "--detach", rev)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	_, err := r.run(cmd)
+	if err != nil {
+		return fmt.Errorf("error checking out %s: %w", rev, err)
+	}
+	return nil
+}
+
+// List the tags, only those pointing at a revision if pointsAt is not empty (eg. to name a detached HEAD)
+func (r *Repo) ListTags(pointsAt string) ([]string, error) {
+	args := []string{"tag", "--list"}
+	if pointsAt != "" {
+		args = append(args, "--points-at", pointsAt)
+	}
+
+	output, err := r.run(r.command(args...))
+	if err != nil {
+		return nil, fmt.Errorf("error listing tags: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// Get the subject of the commit a revision points to
+func (r *Repo) GetCommitSubject(rev string) (string, error) {
+	subject, err := r.Backend().CommitSubject(rev)
+	if err != nil {
+		return "", fmt.Errorf("error reading commit %s: %w", rev, err)
 	}
-	return strings.TrimSpace(out.String()), nil
+	return subject, nil
 }
 
-func GitCheckout(branchName string) error {
-	cmd := exec.Command("git", "checkout", branchName)
-	err := cmd.Run()
+// Get the abbreviated object name of the commit a revision points to, as git shows it (eg. to name a detached HEAD)
+func (r *Repo) GetShortCommit(rev string) (string, error) {
+	output, err := r.run(r.command("rev-parse", "--short", rev+"^{commit}"))
 	if err != nil {
-		return fmt.Errorf("error checking out branch: %v", err)
+		return "", fmt.Errorf("error reading commit %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Create a local branch starting at base, without checking it out
+func (r *Repo) CreateBranch(name string, base string) error {
+	_, err := r.run(r.command("branch", name, base))
+	if err != nil {
+		return fmt.Errorf("error creating branch '%s' from '%s': %w", name, base, err)
 	}
 	return nil
 }
 
+// Check if a ref (branch, tag, commit, ...) resolves to a commit
+func (r *Repo) RefExists(ref string) bool {
+	return r.Backend().RefExists(ref)
+}
+
+// A local branch with its last commit
+type BranchRef struct {
+	Name        string
+	Commit      string // Object name of the tip of the branch
+	AuthorName  string // Author of the last commit
+	AuthorEmail string
+	Subject     string // Subject of the last commit
+}
+
+// List local branches sorted by name, matching one of the patterns (all local branches by default).
+// Patterns are matched against full ref names like git for-each-ref does, eg. refs/heads/feature/*.
+func (r *Repo) ListBranches(patterns ...string) ([]BranchRef, error) {
+	return r.Backend().ListBranches(patterns...)
+}
+
+// List the local branches that are merged into target (git branch --merged), or not merged if merged is false
+func (r *Repo) ListMergedBranches(target string, merged bool) ([]BranchRef, error) {
+	return r.Backend().ListMergedBranches(target, merged)
+}
+
+// Resolve a ref to the object name of the commit it points to
+func (r *Repo) ResolveRef(ref string) (string, error) {
+	return r.Backend().ResolveRef(ref)
+}
+
+// A remote-tracking branch, eg. origin/feature/x
+type RemoteBranchRef struct {
+	Remote string // eg. origin
+	Name   string // Name of the branch on the remote, eg. feature/x
+}
+
+// Full name of the remote-tracking branch, eg. origin/feature/x
+func (r RemoteBranchRef) String() string {
+	return r.Remote + "/" + r.Name
+}
+
+// List the remote-tracking branches of all remotes, without the symbolic <remote>/HEAD refs
+func (r *Repo) ListRemoteBranches() ([]RemoteBranchRef, error) {
+	return r.Backend().ListRemoteBranches()
+}
+
+// Find a remote-tracking branch by its full name (eg. origin/feature/x) or by the name of the
+// branch on the remote (eg. feature/x). If several remotes have the branch, origin is preferred.
+func (r *Repo) FindRemoteBranch(name string) (*RemoteBranchRef, error) {
+	branches, err := r.ListRemoteBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []RemoteBranchRef
+	for _, b := range branches {
+		if b.String() == name {
+			return &b, nil
+		}
+		if b.Name == name {
+			matches = append(matches, b)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no remote-tracking branch named '%s'", name)
+	case 1:
+		return &matches[0], nil
+	}
+
+	for _, b := range matches {
+		if b.Remote == "origin" {
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("branch '%s' exists on several remotes, use <remote>/%s", name, name)
+}
+
+// Create a local branch tracking the remote-tracking branch and check it out.
+// The optional env entries (KEY=value) are passed on to git and its hooks.
+func (r *Repo) GitSwitchTrack(ref RemoteBranchRef, env ...string) error {
+	cmd := r.command("switch", "--track", ref.String())
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	_, err := r.run(cmd)
+	if err != nil {
+		return fmt.Errorf("error creating tracking branch for '%s': %w", ref, err)
+	}
+	return nil
+}
+
+// Checkout a branch. When the branch does not exist locally but the remote it comes from
+// has it, a local branch tracking it is created instead, and the returned bool is true.
+// The optional env entries (KEY=value) are passed on to git and its hooks.
+func (r *Repo) CheckoutBranch(name string, remote string, env ...string) (bool, error) {
+	if remote == "" || r.RefExists("refs/heads/"+name) {
+		return false, r.GitCheckout(name, env...)
+	}
+
+	remoteBranch, err := r.FindRemoteBranch(remote + "/" + name)
+	if err != nil {
+		return false, err
+	}
+	return true, r.GitSwitchTrack(*remoteBranch, env...)
+}
+
+// Get the name of the default branch that work gets merged into, eg. main. It is taken from the
+// gitbm.defaultBranch git config, the origin/HEAD ref, or the first of main and master that exists.
+func (r *Repo) GetDefaultBranch() (string, error) {
+	if branch, ok := r.GetGitConfig("gitbm.defaultBranch"); ok && branch != "" {
+		return branch, nil
+	}
+
+	output, err := r.command("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD").Output()
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"), nil
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		if r.RefExists("refs/heads/" + branch) {
+			return branch, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find the default branch, set it with 'git config gitbm.defaultBranch <branch>'")
+}
+
+// Set a gitbm setting in the git config of the repository
+func (r *Repo) SetGitConfig(key string, value string) error {
+	_, err := r.run(r.command("config", "--local", key, value))
+	if err != nil {
+		return fmt.Errorf("error setting %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get a gitbm setting from the git config (eg. gitbm.idleCap), honoring the usual
+// local > global > system precedence. The bool is false if the key is not set.
+func (r *Repo) GetGitConfig(key string) (string, bool) {
+	cmd := r.command("config", "--get", key)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(output)), true
+}
+
+// List the git config entries whose key starts with prefix (eg. gitbm.template.),
+// keyed by the rest of the key, as lowercased by git
+func (r *Repo) ListGitConfig(prefix string) map[string]string {
+	entries := make(map[string]string)
+	output, err := r.command("config", "--null", "--get-regexp", "^"+regexp.QuoteMeta(prefix)).Output()
+	if err != nil {
+		return entries
+	}
+
+	for _, entry := range strings.Split(string(output), "\x00") {
+		key, value, _ := strings.Cut(entry, "\n")
+		if key == "" {
+			continue
+		}
+		entries[strings.TrimPrefix(key, prefix)] = value
+	}
+	return entries
+}
+
+// Check that name is a valid branch name
+func (r *Repo) CheckBranchName(name string) error {
+	err := r.command("check-ref-format", "--branch", name).Run()
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid branch name", name)
+	}
+	return nil
+}
+
+// A branch switch recorded in the HEAD reflog
+type ReflogCheckout struct {
+	From   string
+	To     string
+	Commit string // Object name of the commit checked out
+	At     time.Time
+}
+
+// Read the branch switches ("checkout: moving from A to B") from the HEAD reflog, oldest first
+func (r *Repo) ReadCheckoutReflog() ([]ReflogCheckout, error) {
+	cmd := r.command("reflog", "show", "--date=unix", "--format=%gd%x09%H%x09%gs", "HEAD", "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading reflog: %w", err)
+	}
+
+	var checkouts []ReflogCheckout
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// eg. HEAD@{1700000000}<TAB><commit><TAB>checkout: moving from main to feature/x
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		selector, commit, subject := fields[0], fields[1], fields[2]
+
+		from, to, ok := parseCheckoutSubject(subject)
+		if !ok {
+			continue
+		}
+
+		start := strings.Index(selector, "@{")
+		if start == -1 || !strings.HasSuffix(selector, "}") {
+			continue
+		}
+		unixTime, err := strconv.ParseInt(selector[start+2:len(selector)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		checkouts = append(checkouts, ReflogCheckout{From: from, To: to, Commit: commit, At: time.Unix(unixTime, 0)})
+	}
+
+	// git lists the newest entries first
+	slices.Reverse(checkouts)
+	return checkouts, nil
+}
+
+// Parse the subject of a reflog entry of a branch switch, eg. "checkout: moving from main to feature/x"
+func parseCheckoutSubject(subject string) (string, string, bool) {
+	moves, ok := strings.CutPrefix(subject, "checkout: moving from ")
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(moves, " to ")
+}
+
+// Follows the HEAD reflog, reading the entries appended to it since the last read
+type ReflogTail struct {
+	path   string
+	offset int64
+}
+
+// Start following the HEAD reflog from its current end
+func (r *Repo) TailReflog() (*ReflogTail, error) {
+	tail := &ReflogTail{path: filepath.Join(GetGitDir(r.Dir), "logs", "HEAD")}
+
+	info, err := os.Stat(tail.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading reflog: %w", err)
+	}
+	if err == nil {
+		tail.offset = info.Size()
+	}
+	return tail, nil
+}
+
+// Read the branch switches appended to the HEAD reflog since the last read, oldest first
+func (t *ReflogTail) ReadCheckouts() ([]ReflogCheckout, error) {
+	file, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading reflog: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading reflog: %w", err)
+	}
+	// The reflog was expired or rewritten, start over from its new end
+	if info.Size() < t.offset {
+		t.offset = info.Size()
+		return nil, nil
+	}
+
+	if _, err := file.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error reading reflog: %w", err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading reflog: %w", err)
+	}
+
+	// Leave a partly written entry for the next read
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	t.offset += int64(complete)
+
+	var checkouts []ReflogCheckout
+	for _, line := range strings.Split(string(data[:complete]), "\n") {
+		// eg. <old> <new> Name <email> 1700000000 +0100<TAB>checkout: moving from main to feature/x
+		header, subject, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		from, to, ok := parseCheckoutSubject(subject)
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(header)
+		if len(fields) < 4 {
+			continue
+		}
+		unixTime, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		checkouts = append(checkouts, ReflogCheckout{From: from, To: to, Commit: fields[1], At: time.Unix(unixTime, 0)})
+	}
+	return checkouts, nil
+}
+
+// Check if a rebase or a bisect is in progress, as they check out many commits along the way
+func (r *Repo) IsRebasingOrBisecting() bool {
+	gitDir := GetGitDir(r.Dir)
+	for _, path := range []string{"rebase-merge", "rebase-apply", "BISECT_LOG"} {
+		if _, err := os.Stat(filepath.Join(gitDir, path)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 //go:embed git-hooks/post-checkout
 var PostCheckoutHook string
 
-func InstallGitHook(gitDir string) error {
-	hooksDir, err := GetGitHooksDir()
+func (r *Repo) InstallGitHook() error {
+	hooksDir, err := r.GetGitHooksDir()
 	if err != nil {
 		return fmt.Errorf("failed to get hooks directory: %w", err)
 	}
@@ -51,26 +475,100 @@ func InstallGitHook(gitDir string) error {
 	return nil
 }
 
+// Remove the post-checkout hook, if it is installed. A post-checkout hook not running gitbm is left alone.
+func (r *Repo) RemoveGitHook() error {
+	hooksDir, err := r.GetGitHooksDir()
+	if err != nil {
+		return fmt.Errorf("failed to get hooks directory: %w", err)
+	}
+
+	state, err := r.GetHookState()
+	if err != nil {
+		return err
+	}
+	if state == HookForeign {
+		return nil
+	}
+
+	err = os.Remove(filepath.Join(hooksDir, "post-checkout"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove post-checkout hook: %w", err)
+	}
+	return nil
+}
+
 func GetGitDir(dir string) string {
 	return filepath.Join(dir, ".git")
 }
 
-func GetGitHooksDir() (string, error) {
-	// Run the git command to get the core.hooksPath value
-	cmd := exec.Command("git", "config", "--get", "core.hooksPath")
-	output, err := cmd.Output() // Capture output here
+// Get the directory git runs the hooks from, honoring core.hooksPath like git does
+// (relative to the working tree, with ~ expanded, ...)
+func (r *Repo) GetGitHooksDir() (string, error) {
+	output, err := r.command("rev-parse", "--git-path", "hooks").Output()
 	if err != nil {
-		// If git config --get fails, return the default hooks directory
-		return filepath.Join(".git", "hooks"), nil
+		return "", fmt.Errorf("error getting hooks directory: %w", err)
 	}
-
-	// Trim any trailing spaces/newlines from the output
 	dir := strings.TrimSpace(string(output))
 
-	// If no custom hooks path is set, fallback to the default .git/hooks directory
-	if dir == "" {
-		dir = filepath.Join(".git", "hooks")
+	// Relative paths are relative to the directory git ran in
+	if !filepath.IsAbs(dir) {
+		base := r.Dir
+		if base == "" {
+			base, err = os.Getwd()
+			if err != nil {
+				return "", fmt.Errorf("error getting hooks directory: %w", err)
+			}
+		}
+		dir = filepath.Join(base, dir)
 	}
-
 	return dir, nil
 }
+
+// Find a program on the PATH of the shell git runs hooks with, which has git's exec path
+// prepended and may differ from the PATH of the terminal, eg. when git is run by an IDE.
+// It runs the shell as a git alias, which git sets up like hooks.
+func (r *Repo) LookPathAsHook(program string) (string, error) {
+	output, err := r.command("-c", "alias.gitbm-look-path=!command -v "+program, "gitbm-look-path").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s not found on the PATH of git hooks", program)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// States of the post-checkout hook of gitbm, see GetHookState
+const (
+	HookInstalled = "installed" // The hook is the one embedded in this version of gitbm
+	HookOutdated  = "outdated"  // The hook is an older, or modified, version of the gitbm hook
+	HookForeign   = "foreign"   // There is a post-checkout hook, but it does not run gitbm
+	HookMissing   = "missing"
+)
+
+// Get the state of the post-checkout hook in the hooks directory
+func (r *Repo) GetHookState() (string, error) {
+	hooksDir, err := r.GetGitHooksDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Hooks can be disabled with a hooks path that is not a directory, eg. /dev/null
+	if info, err := os.Stat(hooksDir); err == nil && !info.IsDir() {
+		return HookMissing, nil
+	}
+
+	hook, err := os.ReadFile(filepath.Join(hooksDir, "post-checkout"))
+	if os.IsNotExist(err) {
+		return HookMissing, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading post-checkout hook: %w", err)
+	}
+
+	switch {
+	case string(hook) == PostCheckoutHook:
+		return HookInstalled, nil
+	case strings.Contains(string(hook), "gitbm track-checkout"):
+		return HookOutdated, nil
+	default:
+		return HookForeign, nil
+	}
+}
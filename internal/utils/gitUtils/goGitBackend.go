@@ -0,0 +1,303 @@
+package gitutils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// Backend reading refs and commits in process with go-git, without forking git
+type goGitBackend struct {
+	repo *git.Repository
+	mu   sync.Mutex // go-git repositories are not safe for concurrent use, eg. by gitbm serve
+}
+
+func newGoGitBackend(dir string) (*goGitBackend, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("error opening repository with go-git: %w", err)
+	}
+	return &goGitBackend{repo: repo}, nil
+}
+
+func (b *goGitBackend) CurrentBranch() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	head, err := b.repo.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		// Detached HEAD, like git rev-parse --abbrev-ref HEAD
+		return "HEAD", nil
+	}
+	return head.Name().Short(), nil
+}
+
+func (b *goGitBackend) PreviousBranch() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// go-git does not read reflogs, so read the HEAD reflog like git does for @{-1}
+	storage, ok := b.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", errors.New("error reading the HEAD reflog: the repository is not on disk")
+	}
+	file, err := storage.Filesystem().Open("logs/HEAD")
+	if err != nil {
+		return "", fmt.Errorf("error reading the HEAD reflog: %w", err)
+	}
+	defer file.Close()
+
+	// Entries are "<old> <new> <committer> <time> <tz><TAB><message>", oldest first, and the
+	// previous branch is the one the last branch switch ("checkout: moving from A to B") left
+	var previous string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		_, message, _ := strings.Cut(scanner.Text(), "\t")
+		moves, ok := strings.CutPrefix(message, "checkout: moving from ")
+		if !ok {
+			continue
+		}
+		if from, _, ok := strings.Cut(moves, " to "); ok {
+			previous = from
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading the HEAD reflog: %w", err)
+	}
+	if previous == "" {
+		return "", errors.New("no previous branch in the HEAD reflog")
+	}
+	return previous, nil
+}
+
+func (b *goGitBackend) RefExists(ref string) bool {
+	_, err := b.ResolveRef(ref)
+	return err == nil
+}
+
+func (b *goGitBackend) ResolveRef(ref string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash, err := b.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return "", fmt.Errorf("error resolving '%s': %w", ref, err)
+	}
+	return hash.String(), nil
+}
+
+func (b *goGitBackend) CommitSubject(rev string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hash, err := b.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", err
+	}
+	commit, err := b.repo.CommitObject(*hash)
+	if err != nil {
+		return "", err
+	}
+	return commitSubject(commit.Message), nil
+}
+
+func (b *goGitBackend) ListBranches(patterns ...string) ([]BranchRef, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.listBranches(patterns)
+}
+
+func (b *goGitBackend) ListMergedBranches(target string, merged bool) ([]BranchRef, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	targetHash, err := b.repo.ResolveRevision(plumbing.Revision(target))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving '%s': %w", target, err)
+	}
+	targetCommit, err := b.repo.CommitObject(*targetHash)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving '%s': %w", target, err)
+	}
+
+	branches, err := b.listBranches(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// A branch is merged if its tip is reachable from the target, so walk the history of the
+	// target once, newest commits first, instead of computing a merge base per branch
+	reached, err := b.reachableTips(targetCommit, branches)
+	if err != nil {
+		return nil, fmt.Errorf("error walking the history of '%s': %w", target, err)
+	}
+
+	var filtered []BranchRef
+	for _, branch := range branches {
+		if reached[plumbing.NewHash(branch.Commit)] == merged {
+			filtered = append(filtered, branch)
+		}
+	}
+	return filtered, nil
+}
+
+// How much older than a tip the walk of reachableTips goes before giving up on reaching it,
+// to allow for clocks skewed between committers, like git name-rev does
+const reachableTipsSlop = 24 * time.Hour
+
+// Find which tips of the branches are reachable from start. The walk stops once every tip is
+// reached, or once the commits left are older than every tip not reached yet: their ancestors
+// are older still, so they can not be tips, unlike a walk of the whole history.
+func (b *goGitBackend) reachableTips(start *object.Commit, branches []BranchRef) (map[plumbing.Hash]bool, error) {
+	reached := make(map[plumbing.Hash]bool, len(branches))
+	for _, branch := range branches {
+		reached[plumbing.NewHash(branch.Commit)] = false
+	}
+
+	// The tips to reach, oldest first
+	type tip struct {
+		hash plumbing.Hash
+		when time.Time
+	}
+	tips := make([]tip, 0, len(reached))
+	for hash := range reached {
+		commit, err := b.repo.CommitObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, tip{hash: hash, when: commit.Committer.When})
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].when.Before(tips[j].when) })
+
+	oldest := 0
+	err := object.NewCommitIterCTime(start, nil, nil).ForEach(func(c *object.Commit) error {
+		if _, ok := reached[c.Hash]; ok {
+			reached[c.Hash] = true
+		}
+		for oldest < len(tips) && reached[tips[oldest].hash] {
+			oldest++
+		}
+		if oldest == len(tips) || c.Committer.When.Before(tips[oldest].when.Add(-reachableTipsSlop)) {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopWalk) {
+		return nil, err
+	}
+	return reached, nil
+}
+
+var errStopWalk = errors.New("stop walking history")
+
+func (b *goGitBackend) ListRemoteBranches() ([]RemoteBranchRef, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remoteConfigs, err := b.repo.Remotes()
+	if err != nil {
+		return nil, fmt.Errorf("error listing remotes: %w", err)
+	}
+	remotes := make([]string, 0, len(remoteConfigs))
+	for _, remote := range remoteConfigs {
+		remotes = append(remotes, remote.Config().Name)
+	}
+
+	refs, err := b.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("error listing remote branches: %w", err)
+	}
+
+	var branches []RemoteBranchRef
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if !ref.Name().IsRemote() || ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		name := strings.TrimPrefix(ref.Name().String(), "refs/remotes/")
+		remote := longestRemotePrefix(remotes, name)
+		if remote == "" {
+			return nil
+		}
+
+		branches = append(branches, RemoteBranchRef{Remote: remote, Name: strings.TrimPrefix(name, remote+"/")})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing remote branches: %w", err)
+	}
+
+	sort.Slice(branches, func(i, j int) bool { return branches[i].String() < branches[j].String() })
+	return branches, nil
+}
+
+// List the local branches matching one of the patterns, with their last commit
+func (b *goGitBackend) listBranches(patterns []string) ([]BranchRef, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"refs/heads/"}
+	}
+
+	refs, err := b.repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("error listing branches: %w", err)
+	}
+
+	// Many branches usually point at the same commits, eg. the tip of the default branch
+	commits := make(map[plumbing.Hash]*object.Commit)
+
+	var branches []BranchRef
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		matched := false
+		for _, pattern := range patterns {
+			if matchRefPattern(ref.Name().String(), pattern) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+
+		commit, ok := commits[ref.Hash()]
+		if !ok {
+			c, err := b.repo.CommitObject(ref.Hash())
+			if err != nil {
+				return fmt.Errorf("error reading the last commit of %s: %w", ref.Name().Short(), err)
+			}
+			commit = c
+			commits[ref.Hash()] = commit
+		}
+
+		branches = append(branches, BranchRef{
+			Name:        ref.Name().Short(),
+			Commit:      ref.Hash().String(),
+			AuthorName:  commit.Author.Name,
+			AuthorEmail: commit.Author.Email,
+			Subject:     commitSubject(commit.Message),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing branches: %w", err)
+	}
+
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches, nil
+}
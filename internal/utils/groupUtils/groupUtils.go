@@ -0,0 +1,136 @@
+package grouputils
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// Validate the rule of a smart bookmark group before saving it
+func ValidateRule(git *gitutils.Repo, ruleType string, rule string) error {
+	if rule == "" {
+		return fmt.Errorf("the %s rule can not be empty", ruleType)
+	}
+
+	switch ruleType {
+	case models.RuleGlob:
+		if _, err := path.Match(rule, ""); err != nil {
+			return fmt.Errorf("invalid glob '%s': %w", rule, err)
+		}
+	case models.RuleRegex:
+		if _, err := regexp.Compile(rule); err != nil {
+			return fmt.Errorf("invalid regex '%s': %w", rule, err)
+		}
+	case models.RuleMerged, models.RuleUnmerged:
+		if !git.RefExists(rule) {
+			return fmt.Errorf("ref '%s' does not exist", rule)
+		}
+	case models.RuleAuthor:
+	default:
+		return fmt.Errorf("unknown rule type '%s'", ruleType)
+	}
+
+	return nil
+}
+
+// List the local branches matching the rule of a smart bookmark group
+func EvaluateRule(git *gitutils.Repo, ruleType string, rule string) ([]gitutils.BranchRef, error) {
+	switch ruleType {
+	case models.RuleGlob:
+		// git for-each-ref matches patterns with fnmatch, or as a prefix up to a slash
+		return git.ListBranches("refs/heads/" + rule)
+	case models.RuleRegex:
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %w", rule, err)
+		}
+		return filterBranches(git, func(b gitutils.BranchRef) bool {
+			return re.MatchString(b.Name)
+		})
+	case models.RuleMerged, models.RuleUnmerged:
+		branches, err := git.ListMergedBranches(rule, ruleType == models.RuleMerged)
+		if err != nil {
+			return nil, err
+		}
+		// The ref itself is always merged into itself, which is not interesting
+		filtered := branches[:0]
+		for _, b := range branches {
+			if b.Name != rule {
+				filtered = append(filtered, b)
+			}
+		}
+		return filtered, nil
+	case models.RuleAuthor:
+		author := strings.ToLower(rule)
+		return filterBranches(git, func(b gitutils.BranchRef) bool {
+			return strings.Contains(strings.ToLower(b.AuthorName), author) ||
+				strings.Contains(strings.ToLower(b.AuthorEmail), author)
+		})
+	default:
+		return nil, fmt.Errorf("unknown rule type '%s'", ruleType)
+	}
+}
+
+// List the local branches satisfying the given predicate
+func filterBranches(git *gitutils.Repo, keep func(gitutils.BranchRef) bool) ([]gitutils.BranchRef, error) {
+	branches, err := git.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []gitutils.BranchRef
+	for _, b := range branches {
+		if keep(b) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered, nil
+}
+
+// Describe the rule of a smart bookmark group for display
+func DescribeRule(bg models.BookmarkGroup) string {
+	switch bg.RuleType {
+	case models.RuleMerged:
+		return fmt.Sprintf("merged into %s", bg.Rule)
+	case models.RuleUnmerged:
+		return fmt.Sprintf("not merged into %s", bg.Rule)
+	case models.RuleAuthor:
+		return fmt.Sprintf("last commit by %s", bg.Rule)
+	default:
+		return fmt.Sprintf("%s %s", bg.RuleType, bg.Rule)
+	}
+}
+
+// List the branches of a bookmark group, evaluating the rule of smart bookmark groups
+func ListGroupBranches(db models.Querier, git *gitutils.Repo, bookmarkGroupID int64) ([]models.Branch, error) {
+	// No bookmark group set
+	if bookmarkGroupID == 0 {
+		return nil, nil
+	}
+
+	bookmarkGroupRepo := models.NewBookmarkGroupRepository(db)
+	bookmarkGroup, err := bookmarkGroupRepo.GetByID(bookmarkGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bookmarkGroup.IsSmart() {
+		branchRepo := models.NewBranchRepository(db)
+		return branchRepo.ListByBookmarkGroupId(bookmarkGroupID)
+	}
+
+	refs, err := EvaluateRule(git, bookmarkGroup.RuleType, bookmarkGroup.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]models.Branch, 0, len(refs))
+	for _, ref := range refs {
+		branches = append(branches, models.Branch{BookmarkGroupID: bookmarkGroupID, Name: ref.Name, Kind: models.BranchKindBranch})
+	}
+	return branches, nil
+}
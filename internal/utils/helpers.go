@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/goombaio/namegenerator"
@@ -15,3 +17,57 @@ func GetRandomName() (name string) {
 
 	return name
 }
+
+// Gets the candidates closest to name, best first, for "did you mean" suggestions.
+// Candidates containing name (or contained in it) always match, others must be
+// within an edit distance of a third of the length of name.
+func CloseMatches(name string, candidates []string, limit int) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+
+	lowerName := strings.ToLower(name)
+	maxDistance := max(len(name)/3, 1)
+
+	var matches []match
+	for _, candidate := range candidates {
+		lowerCandidate := strings.ToLower(candidate)
+		distance := levenshtein(lowerName, lowerCandidate)
+		if distance > maxDistance && !strings.Contains(lowerCandidate, lowerName) && !strings.Contains(lowerName, lowerCandidate) {
+			continue
+		}
+		matches = append(matches, match{candidate, distance})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+
+	var closest []string
+	for i := 0; i < len(matches) && i < limit; i++ {
+		closest = append(closest, matches[i].candidate)
+	}
+	return closest
+}
+
+// Gets the edit distance between two strings
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
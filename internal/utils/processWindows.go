@@ -0,0 +1,36 @@
+//go:build windows
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Detach a command from the console, in its own process group, so that it keeps running
+// after the console is closed
+func DetachCommand(cmd *exec.Cmd) {
+	const detachedProcess = 0x00000008
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// Check if a process is running
+func IsProcessRunning(pid int) bool {
+	// FindProcess opens a handle to the process on Windows, which fails if it exited
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// Stop a process. Windows has no SIGTERM, so it is killed.
+func StopProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
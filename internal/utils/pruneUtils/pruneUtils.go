@@ -0,0 +1,157 @@
+package pruneutils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// A branch whose bookmarks and checkout stats can be pruned
+type Candidate struct {
+	Name      string
+	Reason    string
+	Bookmarks []models.Branch        // Bookmarks of the branch, in any bookmark group
+	Checkout  *models.BranchCheckout // Checkout stats of the branch, nil if never checked out
+}
+
+type Options struct {
+	// Check the branches against git: deleted branches and branches merged into the default branch
+	CheckGit bool
+	// Also prune branches not checked out (or bookmarked) for this long, 0 to disable
+	InactiveAfter time.Duration
+}
+
+// Find the stale branches among the bookmarks and the checkout stats.
+// A branch is stale when it no longer exists locally nor on any remote, when it is fully merged
+// into the default branch, or, if opts.InactiveAfter is set, when it was neither checked out
+// nor bookmarked for that long. Candidates are sorted by branch name.
+func FindStale(git *gitutils.Repo, bookmarks []models.Branch, checkouts []models.BranchCheckout, opts Options, now time.Time) ([]Candidate, error) {
+	candidates := make(map[string]*Candidate)
+	get := func(name string) *Candidate {
+		if c, ok := candidates[name]; ok {
+			return c
+		}
+		c := &Candidate{Name: name}
+		candidates[name] = c
+		return c
+	}
+
+	lastActive := make(map[string]time.Time)
+	for _, b := range bookmarks {
+		get(b.Name).Bookmarks = append(get(b.Name).Bookmarks, b)
+		if b.CreatedAt.After(lastActive[b.Name]) {
+			lastActive[b.Name] = b.CreatedAt
+		}
+	}
+	for i := range checkouts {
+		c := checkouts[i]
+		get(c.Name).Checkout = &c
+		if c.LastCheckedOutAt.After(lastActive[c.Name]) {
+			lastActive[c.Name] = c.LastCheckedOutAt
+		}
+	}
+
+	if opts.CheckGit {
+		if err := checkGit(git, candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.InactiveAfter > 0 {
+		for name, c := range candidates {
+			if c.Reason == "" && now.Sub(lastActive[name]) > opts.InactiveAfter {
+				c.Reason = fmt.Sprintf("inactive for %d days", int(now.Sub(lastActive[name]).Hours()/24))
+			}
+		}
+	}
+
+	var stale []Candidate
+	for _, c := range candidates {
+		if c.Reason != "" {
+			stale = append(stale, *c)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Name < stale[j].Name })
+	return stale, nil
+}
+
+// Mark the candidates that were deleted or merged into the default branch
+func checkGit(git *gitutils.Repo, candidates map[string]*Candidate) error {
+	localBranches, err := git.ListBranches()
+	if err != nil {
+		return err
+	}
+	local := make(map[string]gitutils.BranchRef, len(localBranches))
+	for _, b := range localBranches {
+		local[b.Name] = b
+	}
+
+	remoteBranches, err := git.ListRemoteBranches()
+	if err != nil {
+		return err
+	}
+	remote := make(map[string]bool, len(remoteBranches))
+	for _, b := range remoteBranches {
+		remote[b.Name] = true
+	}
+
+	// Without a default branch, only deleted branches can be detected
+	merged := make(map[string]bool)
+	defaultBranch, err := git.GetDefaultBranch()
+	if err == nil {
+		// Prefer the remote branch, as branches are usually merged upstream first
+		target := defaultBranch
+		if git.RefExists("refs/remotes/origin/" + defaultBranch) {
+			target = "origin/" + defaultBranch
+		}
+
+		mergedBranches, err := git.ListMergedBranches(target, true)
+		if err != nil {
+			return err
+		}
+		defaultTip, _ := git.ResolveRef(target)
+		for _, b := range mergedBranches {
+			// A branch pointing at the tip of the default branch was just created, not merged
+			if b.Commit != defaultTip {
+				merged[b.Name] = true
+			}
+		}
+	}
+
+	for name, c := range candidates {
+		if name == defaultBranch {
+			continue
+		}
+
+		// Tags, commits and refs can be deleted, but not merged
+		if rev, ok := detachedRev(c); ok {
+			if !git.RefExists(rev) {
+				c.Reason = "deleted"
+			}
+			continue
+		}
+
+		_, isLocal := local[name]
+		switch {
+		// Detached HEAD checkouts are tracked by the tag or commit checked out
+		case !isLocal && !remote[name] && !git.RefExists(name):
+			c.Reason = "deleted"
+		case isLocal && merged[name]:
+			c.Reason = fmt.Sprintf("merged into %s", defaultBranch)
+		}
+	}
+	return nil
+}
+
+// The revision of a candidate bookmarked as a tag, commit or ref, the bool is false for branches
+func detachedRev(c *Candidate) (string, bool) {
+	for _, b := range c.Bookmarks {
+		if !b.IsBranch() {
+			return b.Rev(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,164 @@
+package reportutils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+)
+
+// Ways of aggregating the time spent on branches
+const (
+	GroupByDay    = "day"
+	GroupByBranch = "branch"
+	GroupByGroup  = "group"
+)
+
+// Key used for branches which are not bookmarked in any bookmark group
+const NoGroup = "(none)"
+
+// A stretch of time spent on a branch
+type Interval struct {
+	Branch string
+	Start  time.Time
+	End    time.Time
+}
+
+// A line of a report
+type Row struct {
+	Key      string
+	Duration time.Duration
+}
+
+// Compute the time spent on each branch between since and until from the checkout events,
+// which must be sorted oldest first. A branch is considered to be worked on from its checkout
+// until the next checkout, but for no longer than idleCap, as the machine may just have been idle.
+func ComputeIntervals(events []models.CheckoutEvent, since time.Time, until time.Time, idleCap time.Duration) []Interval {
+	var intervals []Interval
+	for i, event := range events {
+		end := until
+		if i+1 < len(events) {
+			end = events[i+1].CheckedOutAt
+		}
+		if idleCap > 0 && end.Sub(event.CheckedOutAt) > idleCap {
+			end = event.CheckedOutAt.Add(idleCap)
+		}
+
+		start := event.CheckedOutAt
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(until) {
+			end = until
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		intervals = append(intervals, Interval{Branch: event.BranchName, Start: start, End: end})
+	}
+	return intervals
+}
+
+// Aggregate intervals into report rows.
+// For GroupByGroup, branchGroups maps branch names to the bookmark groups they belong to,
+// and a branch in several groups is counted under each of them.
+// Rows are sorted by day for GroupByDay, and by the time spent otherwise.
+func Aggregate(intervals []Interval, groupBy string, branchGroups map[string][]string) ([]Row, error) {
+	totals := make(map[string]time.Duration)
+
+	for _, interval := range intervals {
+		switch groupBy {
+		case GroupByDay:
+			// Split intervals spanning midnight between the days
+			start := interval.Start.Local()
+			end := interval.End.Local()
+			for start.Before(end) {
+				y, m, d := start.Date()
+				nextDay := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+				chunkEnd := end
+				if nextDay.Before(end) {
+					chunkEnd = nextDay
+				}
+				totals[start.Format("2006-01-02")] += chunkEnd.Sub(start)
+				start = chunkEnd
+			}
+		case GroupByBranch:
+			totals[interval.Branch] += interval.End.Sub(interval.Start)
+		case GroupByGroup:
+			groups := branchGroups[interval.Branch]
+			if len(groups) == 0 {
+				groups = []string{NoGroup}
+			}
+			for _, group := range groups {
+				totals[group] += interval.End.Sub(interval.Start)
+			}
+		default:
+			return nil, fmt.Errorf("unknown grouping '%s', use one of: day, branch, group", groupBy)
+		}
+	}
+
+	rows := make([]Row, 0, len(totals))
+	for key, duration := range totals {
+		rows = append(rows, Row{Key: key, Duration: duration})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if groupBy == GroupByDay {
+			return rows[i].Key < rows[j].Key
+		}
+		if rows[i].Duration != rows[j].Duration {
+			return rows[i].Duration > rows[j].Duration
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows, nil
+}
+
+// Format a duration as hours and minutes, eg. 3h 05m
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Parse the start of a report period relative to now. Accepted forms are
+// today, yesterday, a weekday (eg. monday, the most recent one including today),
+// a date (2006-01-02), a number of days (eg. 7d) or a Go duration (eg. 36h).
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	switch value {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if value == strings.ToLower(weekday.String()) {
+			daysAgo := (int(now.Weekday()) - int(weekday) + 7) % 7
+			return today.AddDate(0, 0, -daysAgo), nil
+		}
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time '%s', use eg. today, yesterday, monday, 2006-01-02, 7d or 36h", value)
+}
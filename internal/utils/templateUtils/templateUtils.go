@@ -0,0 +1,62 @@
+package templateutils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Template used by gitbm new when no gitbm.template.<name> is configured
+const DefaultTemplate = "{{type}}/{{ticket}}-{{slug}}"
+
+// Variables filled in from the title of the branch rather than prompted for
+const (
+	VarTitle = "title"
+	VarSlug  = "slug"
+)
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// List the variables of a template, eg. type, ticket and slug for {{type}}/{{ticket}}-{{slug}},
+// in order of first appearance
+func Variables(template string) []string {
+	var variables []string
+	seen := make(map[string]bool)
+	for _, match := range variablePattern.FindAllStringSubmatch(template, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// Replace the variables of a template with their values, variables without a value become empty
+func Expand(template string, values map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(template, func(match string) string {
+		return values[variablePattern.FindStringSubmatch(match)[1]]
+	})
+}
+
+// Render a template into a branch name. Variables without a value are left out,
+// along with the separators they leave dangling (eg. feat/-slug becomes feat/slug).
+func Render(template string, values map[string]string) string {
+	return cleanSeparators(Expand(template, values))
+}
+
+var (
+	repeatedSeparators = regexp.MustCompile(`([-_.])[-_.]+`)
+	separatorsAtSlash  = regexp.MustCompile(`[-_.]*/+[-_.]*`)
+)
+
+func cleanSeparators(name string) string {
+	name = separatorsAtSlash.ReplaceAllString(name, "/")
+	name = repeatedSeparators.ReplaceAllString(name, "$1")
+	return strings.Trim(name, "-_./")
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Turn a human readable title into a branch name fragment, eg. "Fix the login page!" into fix-the-login-page
+func Slugify(title string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
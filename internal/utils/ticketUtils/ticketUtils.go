@@ -0,0 +1,123 @@
+package ticketutils
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	templateutils "github.com/devadathanmb/gitbm/internal/utils/templateUtils"
+)
+
+// Pattern of trackers configured without one, matching keys such as JIRA-123
+const DefaultPattern = `\b[A-Z][A-Z0-9]+-[0-9]+\b`
+
+// An issue tracker, configured with the gitbm.tracker.<name>.pattern and gitbm.tracker.<name>.url git configs
+type Tracker struct {
+	Name    string
+	Pattern *regexp.Regexp // Matches ticket keys in branch names, the first capture group is the key if any
+	URL     string         // URL template of a ticket, eg. https://acme.atlassian.net/browse/{{ticket}}
+}
+
+// A ticket key found in a branch name
+type Ticket struct {
+	Key     string
+	Tracker string
+	URL     string // Empty if the tracker has no URL template
+}
+
+// Load the trackers from the git config, sorted by name. Trackers without a pattern use DefaultPattern,
+// and without any configured tracker, a tracker named default without URL is returned.
+func LoadTrackers(git *gitutils.Repo) ([]Tracker, error) {
+	entries := git.ListGitConfig("gitbm.tracker.")
+
+	trackers := make(map[string]*Tracker)
+	for key, value := range entries {
+		dot := strings.LastIndex(key, ".")
+		if dot < 0 {
+			continue
+		}
+		name, setting := key[:dot], key[dot+1:]
+
+		tracker, ok := trackers[name]
+		if !ok {
+			tracker = &Tracker{Name: name}
+			trackers[name] = tracker
+		}
+
+		switch setting {
+		case "pattern":
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gitbm.tracker.%s.pattern '%s': %w", name, value, err)
+			}
+			tracker.Pattern = pattern
+		case "url":
+			tracker.URL = value
+		}
+	}
+
+	if len(trackers) == 0 {
+		return []Tracker{{Name: "default", Pattern: regexp.MustCompile(DefaultPattern)}}, nil
+	}
+
+	list := make([]Tracker, 0, len(trackers))
+	for _, tracker := range trackers {
+		if tracker.Pattern == nil {
+			tracker.Pattern = regexp.MustCompile(DefaultPattern)
+		}
+		list = append(list, *tracker)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Extract the ticket keys of every tracker from a branch name, without duplicates
+func ExtractTickets(branch string, trackers []Tracker) []Ticket {
+	var tickets []Ticket
+	seen := make(map[string]bool)
+	for _, tracker := range trackers {
+		for _, match := range tracker.Pattern.FindAllStringSubmatch(branch, -1) {
+			key := match[0]
+			if len(match) > 1 && match[1] != "" {
+				key = match[1]
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			ticket := Ticket{Key: key, Tracker: tracker.Name}
+			if tracker.URL != "" {
+				// Branch names have slashes, eg. feature/JIRA-123, which must not split the path
+				ticket.URL = templateutils.Expand(tracker.URL, map[string]string{
+					"ticket": url.PathEscape(key),
+					"branch": url.PathEscape(branch),
+				})
+			}
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets
+}
+
+// Keys of the tickets
+func Keys(tickets []Ticket) []string {
+	keys := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		keys = append(keys, ticket.Key)
+	}
+	return keys
+}
+
+// Get the pull request URL of a branch from the gitbm.prUrl git config template,
+// eg. https://github.com/acme/app/compare/{{branch}}
+func GetPullRequestURL(git *gitutils.Repo, branch string) (string, error) {
+	template, ok := git.GetGitConfig("gitbm.prUrl")
+	if !ok || template == "" {
+		return "", fmt.Errorf("no pull request URL template, set it with 'git config gitbm.prUrl <url>', eg. https://github.com/<owner>/<repo>/compare/{{branch}}")
+	}
+	return templateutils.Expand(template, map[string]string{"branch": url.PathEscape(branch)}), nil
+}
@@ -0,0 +1,297 @@
+package gitbm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/db"
+	"github.com/devadathanmb/gitbm/internal/utils"
+	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// Git config key of the number of backups kept, the oldest ones are removed past it
+const BackupRetentionConfigKey = "gitbm.backupRetention"
+
+// Number of backups kept when gitbm.backupRetention is not set
+const DefaultBackupRetention = 10
+
+// What a backup was taken before
+const (
+	BackupManual         = "manual" // gitbm backup create
+	BackupDestroy        = "destroy"
+	BackupDelete         = "delete"
+	BackupResetCheckouts = "reset-checkouts"
+	BackupRestore        = "restore" // The database replaced by a restored backup
+)
+
+// Returned by Restore when there is no backup with the given ID
+var ErrBackupNotFound = errors.New("backup not found, see 'gitbm backup list'")
+
+// A snapshot of the gitbm database, stored in .git/gitbm-backups as <id>_<reason>.db
+type Backup struct {
+	ID        string // When it was taken, eg. 20261019-125300, with a -2 suffix if two were taken in the same second
+	Reason    string
+	CreatedAt time.Time
+	Size      int64
+	Path      string
+}
+
+// Layout of the time in backup IDs, sorting them from the oldest
+const backupIDLayout = "20060102-150405"
+
+var backupFileRegex = regexp.MustCompile(`^(\d{8}-\d{6})(?:-(\d+))?_([a-z-]+)\.db$`)
+
+// Directory the backups of the repository whose working tree is at dir are stored in
+func backupDir(dir string) string {
+	return filepath.Join(gitutils.GetGitDir(dir), "gitbm-backups")
+}
+
+// Back up the database, then remove the oldest backups past the retention (see BackupRetention)
+func (c *Client) Backup(reason string) (*Backup, error) {
+	backup, err := c.backup(reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := pruneBackups(c.dir, BackupRetention(c.dir)); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// Back up the database, leaving the old backups
+func (c *Client) backup(reason string) (*Backup, error) {
+	backup, err := c.newBackup(reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Backup(c.db, backup.Path); err != nil {
+		os.Remove(backup.Path)
+		return nil, err
+	}
+	if info, err := os.Stat(backup.Path); err == nil {
+		backup.Size = info.Size()
+	}
+	return backup, nil
+}
+
+// Reserve the file of a new backup, named after the current time
+func (c *Client) newBackup(reason string) (*Backup, error) {
+	dir := backupDir(c.dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	now := time.Now()
+	for {
+		id := nextBackupID(dir, now)
+		path := filepath.Join(dir, id+"_"+reason+".db")
+		// Creating the file exclusively guards against another backup taken at the same time
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return &Backup{ID: id, Reason: reason, CreatedAt: now, Path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error creating backup file: %w", err)
+		}
+	}
+}
+
+// Get the ID of a backup taken at t, suffixed to sort after the backups taken in the same second
+func nextBackupID(dir string, t time.Time) string {
+	id := t.Format(backupIDLayout)
+	matches, _ := filepath.Glob(filepath.Join(dir, id+"*.db"))
+	last := 0
+	for _, match := range matches {
+		parts := backupFileRegex.FindStringSubmatch(filepath.Base(match))
+		if parts == nil {
+			continue
+		}
+		seq := 1
+		if parts[2] != "" {
+			seq, _ = strconv.Atoi(parts[2])
+		}
+		last = max(last, seq)
+	}
+	if last == 0 {
+		return id
+	}
+	return fmt.Sprintf("%s-%d", id, last+1)
+}
+
+// Get the number of backups kept from the gitbm.backupRetention git config,
+// DefaultBackupRetention if it is not set or is not a positive number
+func BackupRetention(dir string) int {
+	value, ok := gitutils.NewRepo(dir).GetGitConfig(BackupRetentionConfigKey)
+	if !ok {
+		return DefaultBackupRetention
+	}
+	retention, err := strconv.Atoi(value)
+	if err != nil || retention < 1 {
+		return DefaultBackupRetention
+	}
+	return retention
+}
+
+// Remove the oldest backups, keeping the given number of them
+func pruneBackups(dir string, keep int) error {
+	backups, err := Backups(dir)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(backups)-keep; i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return fmt.Errorf("error removing old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// List the backups of the repository whose working tree is at dir, oldest first.
+// Unlike the methods of Client, it works when gitbm is not initialized, eg. after destroy.
+func Backups(dir string) ([]Backup, error) {
+	dir, err := checkRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(backupDir(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup directory: %w", err)
+	}
+
+	var backups []Backup
+	seq := make(map[string]int)
+	for _, entry := range entries {
+		match := backupFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		createdAt, err := time.ParseInLocation(backupIDLayout, match[1], time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		id := match[1]
+		if match[2] != "" {
+			id += "-" + match[2]
+			seq[id], _ = strconv.Atoi(match[2])
+		}
+		backups = append(backups, Backup{
+			ID:        id,
+			Reason:    match[3],
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+			Path:      filepath.Join(backupDir(dir), entry.Name()),
+		})
+	}
+
+	// IDs sort by time, then by the suffix of the backups taken in the same second
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.Before(backups[j].CreatedAt)
+		}
+		return seq[backups[i].ID] < seq[backups[j].ID]
+	})
+	return backups, nil
+}
+
+// Find a backup by its ID, or the most recent one with "latest"
+func findBackup(dir string, id string) (*Backup, error) {
+	backups, err := Backups(dir)
+	if err != nil {
+		return nil, err
+	}
+	if id == "latest" && len(backups) > 0 {
+		return &backups[len(backups)-1], nil
+	}
+	for _, backup := range backups {
+		if backup.ID == id {
+			return &backup, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, id)
+}
+
+// Restore the backup with the given ID ("latest" for the most recent one) in the repository
+// whose working tree is at dir, or in the database given by WithDB. The database it replaces
+// is backed up first, so a restore can be undone. If gitbm was destroyed it is initialized
+// again, tracking checkouts as set in git config (see SetTracking). The client is opened
+// on the restored database.
+func Restore(dir string, id string, opts ...Option) (*Client, *Backup, error) {
+	dir, err := checkRepo(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	backup, err := findBackup(dir, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbFilePath := dbutils.GetDBPath(dir)
+	doesDBExist, err := utils.DoesDBExist(dbFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking if database file exists: %w", err)
+	}
+
+	if doesDBExist || newOptions(opts).db != nil {
+		client, err := Open(dir, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The old backups are pruned once restored, as the one being restored may be the oldest
+		if _, err := client.backup(BackupRestore); err != nil {
+			client.Close()
+			return nil, nil, err
+		}
+		err = db.Restore(client.db, backup.Path)
+		client.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := pruneBackups(dir, BackupRetention(dir)); err != nil {
+			return nil, nil, err
+		}
+		// Reopen to bring a backup taken by an older version of gitbm up to date
+		client, err = Open(dir, opts...)
+		return client, backup, err
+	}
+
+	if err := dbutils.CreateDB(dbFilePath); err != nil {
+		return nil, nil, err
+	}
+	database, err := db.GetDB(dbFilePath)
+	if err == nil {
+		err = db.Restore(database, backup.Path)
+		database.Close()
+	}
+	if err != nil {
+		os.Remove(dbFilePath)
+		return nil, nil, err
+	}
+
+	client, err := Open(dir, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := client.SetTracking(client.Tracking()); err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, backup, nil
+}
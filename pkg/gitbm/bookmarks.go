@@ -0,0 +1,332 @@
+package gitbm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	grouputils "github.com/devadathanmb/gitbm/internal/utils/groupUtils"
+)
+
+// List the bookmarks of a bookmark group (the current one if group is empty), with their labels.
+// The branches of smart groups are evaluated against the git refs.
+func (c *Client) Bookmarks(group string) ([]Bookmark, error) {
+	bg, err := c.Group(group)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := c.groupBookmarks(*bg)
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, c.loadTags(bookmarks)
+}
+
+// List the bookmarks of every bookmark group, smart groups included, with their labels.
+// Smart groups whose rule fails to evaluate are skipped: the bookmarks of the other groups
+// are returned along with a SmartGroupError for each of them.
+func (c *Client) AllBookmarks() ([]Bookmark, error) {
+	groups, err := c.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := models.NewBranchRepository(c.conn()).ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var smartGroupErrs []error
+	for _, bg := range groups {
+		if !bg.IsSmart() {
+			continue
+		}
+
+		smartBookmarks, err := c.groupBookmarks(bg)
+		if err != nil {
+			smartGroupErrs = append(smartGroupErrs, err)
+			continue
+		}
+		bookmarks = append(bookmarks, smartBookmarks...)
+	}
+
+	if err := c.loadTags(bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, errors.Join(smartGroupErrs...)
+}
+
+// List the bookmarks having every one of the given labels, across all bookmark groups
+func (c *Client) BookmarksByTags(tags []string) ([]Bookmark, error) {
+	bookmarks, err := models.NewBranchRepository(c.conn()).ListByTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, c.loadTags(bookmarks)
+}
+
+// Get the bookmark of a branch in a bookmark group (the current one if group is empty)
+func (c *Client) Bookmark(group string, branch string) (*Bookmark, error) {
+	bookmarks, err := c.Bookmarks(group)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bookmarks {
+		if b.Name == branch {
+			return &b, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBookmarkNotFound, branch)
+}
+
+// Bookmark a branch in a bookmark group (the current one if group is empty).
+// The name, alias, remote and notes of b are saved, and its ID and group are filled in.
+// The branch itself is not checked, it does not have to exist yet.
+func (c *Client) AddBookmark(group string, b *Bookmark) error {
+	return c.inTx(func(c *Client) error {
+		return c.addBookmark(group, b)
+	})
+}
+
+func (c *Client) addBookmark(group string, b *Bookmark) error {
+	bg, err := c.Group(group)
+	if err != nil {
+		return err
+	}
+	if err := ensureExplicitGroup(*bg); err != nil {
+		return err
+	}
+
+	bookmarks, err := c.groupBookmarks(*bg)
+	if err != nil {
+		return err
+	}
+	for _, existing := range bookmarks {
+		if existing.Name == b.Name {
+			return fmt.Errorf("%w: %s", ErrBookmarkExists, b.Name)
+		}
+	}
+
+	b.BookmarkGroupID = bg.ID
+	if err := models.NewBranchRepository(c.conn()).Create(b); err != nil {
+		return err
+	}
+	return c.record("add", fmt.Sprintf("Add %s to %s", b.DisplayName(), bg.Name), step{Kind: stepAddBookmark, Bookmark: snapshotBookmark(*b, bg.Name)})
+}
+
+// Remove a bookmark from its bookmark group
+func (c *Client) RemoveBookmark(b Bookmark) error {
+	return c.inTx(func(c *Client) error {
+		return c.removeBookmark(b)
+	})
+}
+
+func (c *Client) removeBookmark(b Bookmark) error {
+	bg, err := models.NewBookmarkGroupRepository(c.conn()).GetByID(b.BookmarkGroupID)
+	if err != nil {
+		return err
+	}
+	if err := ensureExplicitGroup(*bg); err != nil {
+		return err
+	}
+
+	// Make sure there is something to remove, and snapshot it with its notes and labels to undo it
+	stored, err := c.storedBookmark(b.BookmarkGroupID, b.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := models.NewBranchRepository(c.conn()).Remove(b.BookmarkGroupID, b.Name); err != nil {
+		return err
+	}
+	return c.record("remove", fmt.Sprintf("Remove %s from %s", b.DisplayName(), bg.Name), step{Kind: stepRemoveBookmark, Bookmark: snapshotBookmark(*stored, bg.Name)})
+}
+
+// Replace the notes of a bookmark
+func (c *Client) SetNotes(b Bookmark, notes string) error {
+	return c.inTx(func(c *Client) error {
+		return c.setNotes(b, notes)
+	})
+}
+
+func (c *Client) setNotes(b Bookmark, notes string) error {
+	if b.ID == 0 {
+		return fmt.Errorf("%w: %s has no notes", ErrSmartGroup, b.Name)
+	}
+
+	bg, err := models.NewBookmarkGroupRepository(c.conn()).GetByID(b.BookmarkGroupID)
+	if err != nil {
+		return err
+	}
+	stored, err := c.storedBookmark(b.BookmarkGroupID, b.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := models.NewBranchRepository(c.conn()).UpdateNotes(b.BookmarkGroupID, b.Name, notes); err != nil {
+		return err
+	}
+	if stored.Notes == notes {
+		return nil
+	}
+
+	description := fmt.Sprintf("Set the notes of %s in %s", b.DisplayName(), bg.Name)
+	if notes == "" {
+		description = fmt.Sprintf("Clear the notes of %s in %s", b.DisplayName(), bg.Name)
+	}
+	return c.record("note", description, step{Kind: stepSetNotes, Bookmark: snapshotBookmark(*stored, bg.Name), From: stored.Notes, To: notes})
+}
+
+// Search the notes of the bookmarks of every bookmark group.
+// Every word of the query must appear in the notes (case-insensitive).
+func (c *Client) SearchNotes(query string) ([]Bookmark, error) {
+	bookmarks, err := models.NewBranchRepository(c.conn()).SearchNotes(query)
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, c.loadTags(bookmarks)
+}
+
+// Add a label to a bookmark, b.Tags is updated
+func (c *Client) AddTag(b *Bookmark, tag string) error {
+	return c.UpdateTags(b, []string{tag}, nil)
+}
+
+// Remove a label from a bookmark, b.Tags is updated
+func (c *Client) RemoveTag(b *Bookmark, tag string) error {
+	return c.UpdateTags(b, nil, []string{tag})
+}
+
+// Add labels to a bookmark and remove others from it, in that order, b.Tags is updated.
+// The changes are recorded as one operation of the undo log, so they are undone together.
+func (c *Client) UpdateTags(b *Bookmark, add []string, remove []string) error {
+	if b.ID == 0 {
+		return fmt.Errorf("%w: %s can not be labelled", ErrSmartGroup, b.Name)
+	}
+	return c.inTx(func(c *Client) error {
+		return c.updateTags(b, add, remove)
+	})
+}
+
+func (c *Client) updateTags(b *Bookmark, add []string, remove []string) error {
+	if err := c.reloadTags(b); err != nil {
+		return err
+	}
+
+	// Only the labels actually added or removed are recorded
+	tags := slices.Clone(b.Tags)
+	tagRepo := models.NewTagRepository(c.conn())
+	var steps []step
+	for _, tag := range add {
+		if slices.Contains(tags, tag) {
+			continue
+		}
+		if err := tagRepo.AddToBranch(b.ID, tag); err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+		steps = append(steps, step{Kind: stepAddTag, Tag: tag})
+	}
+	for _, tag := range remove {
+		i := slices.Index(tags, tag)
+		if i < 0 {
+			continue
+		}
+		if err := tagRepo.RemoveFromBranch(b.ID, tag); err != nil {
+			return err
+		}
+		tags = slices.Delete(tags, i, i+1)
+		steps = append(steps, step{Kind: stepRemoveTag, Tag: tag})
+	}
+
+	if err := c.reloadTags(b); err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	return c.recordTags(*b, steps)
+}
+
+// Record the labelling of a bookmark in the undo log, with one step per label
+func (c *Client) recordTags(b Bookmark, steps []step) error {
+	bg, err := models.NewBookmarkGroupRepository(c.conn()).GetByID(b.BookmarkGroupID)
+	if err != nil {
+		return err
+	}
+
+	snapshot := snapshotBookmark(b, bg.Name)
+	changes := make([]string, 0, len(steps))
+	for i, s := range steps {
+		steps[i].Bookmark = snapshot
+		if s.Kind == stepAddTag {
+			changes = append(changes, "+"+s.Tag)
+		} else {
+			changes = append(changes, "-"+s.Tag)
+		}
+	}
+
+	var description string
+	switch {
+	case len(steps) > 1:
+		description = fmt.Sprintf("Change the labels of %s in %s: %s", b.DisplayName(), bg.Name, strings.Join(changes, " "))
+	case steps[0].Kind == stepAddTag:
+		description = fmt.Sprintf("Label %s in %s with %s", b.DisplayName(), bg.Name, steps[0].Tag)
+	default:
+		description = fmt.Sprintf("Remove the label %s from %s in %s", steps[0].Tag, b.DisplayName(), bg.Name)
+	}
+	return c.record("tag", description, steps...)
+}
+
+// List all labels with the number of bookmarks having them
+func (c *Client) Tags() ([]Tag, error) {
+	return models.NewTagRepository(c.conn()).List()
+}
+
+// Get a map of bookmark group IDs to their names, eg. to show the group of bookmarks
+func (c *Client) GroupNames() (map[int64]string, error) {
+	groups, err := c.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	groupNames := make(map[int64]string, len(groups))
+	for _, bg := range groups {
+		groupNames[bg.ID] = bg.Name
+	}
+	return groupNames, nil
+}
+
+// List the bookmarks of a bookmark group, evaluating the rule of smart bookmark groups
+func (c *Client) groupBookmarks(bg Group) ([]Bookmark, error) {
+	bookmarks, err := grouputils.ListGroupBranches(c.conn(), c.git, bg.ID)
+	if err != nil && bg.IsSmart() {
+		return nil, &SmartGroupError{Group: bg.Name, Err: err}
+	}
+	return bookmarks, err
+}
+
+func (c *Client) loadTags(bookmarks []Bookmark) error {
+	return models.NewTagRepository(c.conn()).LoadForBranches(bookmarks)
+}
+
+func (c *Client) reloadTags(b *Bookmark) error {
+	bookmarks := []Bookmark{*b}
+	if err := c.loadTags(bookmarks); err != nil {
+		return err
+	}
+	b.Tags = bookmarks[0].Tags
+	return nil
+}
+
+// Fails for smart bookmark groups, as their branches can not be added or removed
+func ensureExplicitGroup(bg Group) error {
+	if bg.IsSmart() {
+		return fmt.Errorf("%w: %s is a smart group (%s)", ErrSmartGroup, bg.Name, grouputils.DescribeRule(bg))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package gitbm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// Checkout the bookmark of a branch in a bookmark group (the current one if group is empty).
+// See CheckoutBookmark for the returned bool.
+func (c *Client) Checkout(group string, branch string) (bool, error) {
+	b, err := c.Bookmark(group, branch)
+	if err != nil {
+		return false, err
+	}
+	return c.CheckoutBookmark(*b)
+}
+
+// Checkout a bookmark. Bookmarks of tags, commits and refs are checked out as a detached HEAD.
+// When a bookmarked branch does not exist locally yet but exists on the remote it was
+// bookmarked from, a local branch tracking it is created first, and the returned bool is true.
+func (c *Client) CheckoutBookmark(b Bookmark) (bool, error) {
+	if !b.IsBranch() {
+		return false, c.git.CheckoutDetached(b.Rev())
+	}
+	return c.git.CheckoutBranch(b.Name, b.Remote)
+}
+
+// Checkout any local branch, eg. one of RecentBranches
+func (c *Client) CheckoutBranch(branch string) error {
+	return c.git.GitCheckout(branch)
+}
+
+// Checkout the branch visited the given number of steps back, or forward, in the navigation
+// history. If fewer steps are available, it goes as far as possible: the entry checked out is
+// returned along with the number of steps taken. ErrNoHistory is returned if there is nowhere to go.
+func (c *Client) Jump(steps int, forward bool) (*NavigationEntry, int, error) {
+	if steps < 1 {
+		return nil, 0, fmt.Errorf("invalid number of steps: %d", steps)
+	}
+
+	navigationRepo := models.NewNavigationRepository(c.conn())
+	entry, available, err := navigationRepo.Peek(steps, forward)
+	if err != nil {
+		return nil, 0, err
+	}
+	if entry == nil {
+		return nil, 0, ErrNoHistory
+	}
+
+	// Move the cursor first, so that gitbm watch, which does not see the environment of the
+	// checkout, finds the branch on the cursor and does not record a new visit. It moves back
+	// if the checkout fails.
+	cursor, err := navigationRepo.GetCursor()
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := navigationRepo.SetCursor(entry.ID); err != nil {
+		return nil, 0, err
+	}
+
+	// Let the post-checkout hook know that this checkout is a navigation
+	err = c.git.GitCheckout(entry.BranchName, gitutils.SkipNavigationEnv+"=1")
+	if err != nil {
+		if cursorErr := navigationRepo.SetCursor(cursor); cursorErr != nil {
+			return nil, 0, errors.Join(err, cursorErr)
+		}
+		return nil, 0, err
+	}
+	return entry, min(available, steps), nil
+}
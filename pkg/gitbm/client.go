@@ -0,0 +1,295 @@
+// Package gitbm is the Go API of gitbm, for tools that embed it instead of running the gitbm binary.
+//
+// A Client is opened on the working tree of a git repository initialized with gitbm,
+// and exposes its bookmark groups, bookmarks, checkout history and checkout/jump operations:
+//
+//	client, err := gitbm.Open("/path/to/repo")
+//	if errors.Is(err, gitbm.ErrNotInitialized) {
+//		client, err = gitbm.Init("/path/to/repo", gitbm.TrackingHook)
+//	}
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	bookmarks, err := client.Bookmarks("")
+package gitbm
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/db"
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	"github.com/devadathanmb/gitbm/internal/utils"
+	dbutils "github.com/devadathanmb/gitbm/internal/utils/dbUtils"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// A bookmark group, either explicit (branches are added to it) or smart (branches matching a rule)
+type Group = models.BookmarkGroup
+
+// A bookmarked branch. Bookmarks of smart groups are not stored, so their ID is 0 and they have no alias.
+type Bookmark = models.Branch
+
+// Checkout stats of a branch: how many times and when it was last checked out
+type BranchStats = models.BranchCheckout
+
+// An entry of the back/forward navigation history
+type NavigationEntry = models.NavigationEntry
+
+// A checkout logged by the post-checkout hook or imported from the reflog
+type CheckoutEvent = models.CheckoutEvent
+
+// A label along with the number of bookmarks having it
+type Tag = models.Tag
+
+// Rule types of smart bookmark groups
+const (
+	RuleGlob     = models.RuleGlob
+	RuleRegex    = models.RuleRegex
+	RuleMerged   = models.RuleMerged
+	RuleUnmerged = models.RuleUnmerged
+	RuleAuthor   = models.RuleAuthor
+)
+
+// Kinds of bookmarks, see Bookmark.Kind. Anything but a branch is checked out as a detached HEAD.
+const (
+	KindBranch = models.BranchKindBranch
+	KindTag    = models.BranchKindTag
+	KindCommit = models.BranchKindCommit
+	KindRef    = models.BranchKindRef
+)
+
+// Reads a git repository without running git, see WithGitBackend
+type GitBackend = gitutils.Backend
+
+// A gitbm client for one repository. It holds a database connection, so it must be closed.
+type Client struct {
+	dir    string
+	db     *sql.DB
+	tx     *sql.Tx // Transaction the changes are made in, see inTx
+	git    *gitutils.Repo
+	ownsDB bool // Whether Close closes db, false for the database given by WithDB
+}
+
+// Options of Open and Init, to embed gitbm with a database or a git backend of the caller's
+// choice, eg. to drive it in tests
+type Option func(*options)
+
+type options struct {
+	db  *sql.DB
+	git GitBackend
+}
+
+// Use a database opened by the caller instead of the database file of the repository,
+// eg. an in-memory database. Its tables are created if needed. The caller closes it,
+// so that several clients can be opened on it in turn.
+func WithDB(database *sql.DB) Option {
+	return func(o *options) {
+		o.db = database
+	}
+}
+
+// Read the repository with the given backend instead of the one set by the gitbm.gitBackend
+// git config. Changes to the repository, eg. checkouts, always run git.
+func WithGitBackend(backend GitBackend) Option {
+	return func(o *options) {
+		o.git = backend
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Create the client of a repository whose database is open
+func newClient(dir string, database *sql.DB, ownsDB bool, o options) *Client {
+	git := gitutils.NewRepo(dir)
+	if o.git != nil {
+		git = gitutils.NewRepoWithBackend(dir, o.git)
+	}
+	return &Client{dir: dir, db: database, git: git, ownsDB: ownsDB}
+}
+
+// Open a client on the database given by WithDB, creating its tables if needed
+func openWithDB(dir string, o options) (*Client, error) {
+	if err := db.SetupDB(o.db); err != nil {
+		return nil, fmt.Errorf("error initializing database: %w", err)
+	}
+	return newClient(dir, o.db, false, o), nil
+}
+
+// Open the gitbm database of the repository whose working tree is at dir.
+// It fails with ErrNotGitRepo or ErrNotInitialized if there is nothing to open.
+func Open(dir string, opts ...Option) (*Client, error) {
+	dir, err := checkRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+	if o.db != nil {
+		return openWithDB(dir, o)
+	}
+
+	dbFilePath := dbutils.GetDBPath(dir)
+	doesDBExist, err := utils.DoesDBExist(dbFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error checking if database file exists: %w", err)
+	}
+	if !doesDBExist {
+		return nil, ErrNotInitialized
+	}
+
+	database, err := db.GetDB(dbFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting db connection: %w", err)
+	}
+
+	return newClient(dir, database, true, o), nil
+}
+
+// Initialize gitbm in the repository whose working tree is at dir: create its database
+// and set up how checkouts are tracked (see SetTracking). The client is opened on it.
+// It fails with ErrAlreadyInitialized if gitbm is already initialized.
+func Init(dir string, tracking string, opts ...Option) (*Client, error) {
+	if !isTrackingMode(tracking) {
+		return nil, fmt.Errorf("invalid tracking mode '%s', must be one of %s", tracking, strings.Join(TrackingModes, ", "))
+	}
+
+	dir, err := checkRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The database given by WithDB is initialized by opening it
+	if newOptions(opts).db != nil {
+		return initTracking(dir, tracking, opts)
+	}
+
+	dbFilePath := dbutils.GetDBPath(dir)
+	doesDBExist, err := utils.DoesDBExist(dbFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error checking if database file exists: %w", err)
+	}
+	if doesDBExist {
+		return nil, ErrAlreadyInitialized
+	}
+
+	if err := dbutils.CreateDB(dbFilePath); err != nil {
+		return nil, err
+	}
+
+	// Initialize the database and run migrations
+	if err := db.InitDB(dbFilePath); err != nil {
+		return nil, fmt.Errorf("error initializing database: %w", err)
+	}
+
+	return initTracking(dir, tracking, opts)
+}
+
+// Open the client of a repository being initialized and set up how checkouts are tracked
+func initTracking(dir string, tracking string, opts []Option) (*Client, error) {
+	client, err := Open(dir, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.SetTracking(tracking); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Check that dir is the working tree of a git repository, returning its absolute path
+func checkRepo(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("error resolving repository path: %w", err)
+	}
+
+	isGitDir, err := utils.IsGitDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("error checking if directory is a git repository: %w", err)
+	}
+	if !isGitDir {
+		return "", fmt.Errorf("%w: %s", ErrNotGitRepo, dir)
+	}
+	return dir, nil
+}
+
+// Close the database connection
+func (c *Client) Close() error {
+	if !c.ownsDB {
+		return nil
+	}
+	return c.db.Close()
+}
+
+// Root of the working tree of the repository
+func (c *Client) Dir() string {
+	return c.dir
+}
+
+// What the repositories query: the transaction of inTx if there is one, the database otherwise
+func (c *Client) conn() models.Querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+// Run fn with a client whose changes are committed if fn succeeds and rolled back if it fails,
+// so that they are made together or not at all, eg. a change and its record in the undo log.
+// The whole transaction is run again if the database is busy, so fn must change nothing but
+// the database. If c is already in a transaction, fn runs in it.
+func (c *Client) inTx(fn func(c *Client) error) error {
+	if c.tx != nil {
+		return fn(c)
+	}
+
+	return models.RetryOnBusy(func() error {
+		tx, err := c.db.Begin()
+		if err != nil {
+			return fmt.Errorf("error starting transaction: %w", err)
+		}
+		txClient := *c
+		txClient.tx = tx
+		if err := fn(&txClient); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// Remove the gitbm database and the post-checkout hook of the repository, closing the client
+func (c *Client) Destroy() error {
+	// A database given by WithDB is left to the caller, there may be no database file then
+	c.Close()
+
+	dbFilePath := dbutils.GetDBPath(c.dir)
+	if err := os.Remove(dbFilePath); err != nil && (c.ownsDB || !os.IsNotExist(err)) {
+		return fmt.Errorf("error removing gitbm database: %w", err)
+	}
+	// The write-ahead log is left behind if another process had the database open
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbFilePath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing gitbm database: %w", err)
+		}
+	}
+
+	// The hook is not installed if checkouts are tracked by gitbm watch, or not at all
+	if err := c.git.RemoveGitHook(); err != nil {
+		return fmt.Errorf("error removing gitbm hook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package gitbm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/testutil"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Environment variables making the test binary record checkouts instead of running the tests,
+// to write to the database from other processes, see recordCheckoutsInProcess
+const (
+	recordDirEnv    = "GITBM_TEST_RECORD_DIR"
+	recordWriterEnv = "GITBM_TEST_RECORD_WRITER"
+)
+
+const (
+	writerGoroutines = 8
+	writerProcesses  = 4
+	writerCheckouts  = 25 // Per goroutine or process
+)
+
+func TestMain(m *testing.M) {
+	if dir := os.Getenv(recordDirEnv); dir != "" {
+		recorded, err := recordCheckouts(dir, os.Getenv(recordWriterEnv))
+		fmt.Println(recorded)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Record checkouts like the post-checkout hook does in every process of a scripted rebase,
+// from goroutines sharing a client and from processes each opening their own, and check that
+// none of them failed because the database was busy, nor was lost.
+func TestRecordCheckoutConcurrently(t *testing.T) {
+	dir := testutil.NewRepo(t)
+	client, err := Init(dir, TrackingNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var journalMode string
+	if err := client.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		t.Fatal(err)
+	}
+	if journalMode != "wal" {
+		t.Fatalf("journal mode is %s, want wal", journalMode)
+	}
+
+	var wg sync.WaitGroup
+	var calls atomic.Int64 // RecordCheckout calls that succeeded
+	errs := make(chan error, writerGoroutines+writerProcesses)
+	for g := 0; g < writerGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorded, err := recordCheckoutsWith(client, fmt.Sprintf("goroutine-%d", g))
+			calls.Add(int64(recorded))
+			errs <- err
+		}()
+	}
+	for p := 0; p < writerProcesses; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorded, err := recordCheckoutsInProcess(dir, fmt.Sprintf("process-%d", p))
+			calls.Add(int64(recorded))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrBusy {
+			t.Errorf("database busy: %v", err)
+		} else if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Every checkout has a branch of its own, so that a lost event is told from another
+	events, err := client.CheckoutEvents(time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := make(map[string]int, len(events))
+	for _, event := range events {
+		recorded[event.BranchName]++
+	}
+	var missing []string
+	for _, writer := range writerNames() {
+		for i := 0; i < writerCheckouts; i++ {
+			if branch := checkoutBranch(writer, i); recorded[branch] != 1 {
+				missing = append(missing, branch)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		t.Errorf("%d checkouts without exactly one checkout event, eg. %s", len(missing), missing[0])
+	}
+
+	// A checkout is recorded whole or not at all: its stats and its event go together
+	var checkouts, eventRows int
+	if err := client.db.QueryRow("SELECT COALESCE(SUM(checkout_count), 0) FROM branch_checkouts").Scan(&checkouts); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.db.QueryRow("SELECT COUNT(*) FROM checkout_events").Scan(&eventRows); err != nil {
+		t.Fatal(err)
+	}
+	if checkouts != eventRows || int64(eventRows) != calls.Load() {
+		t.Errorf("checkout count is %d, with %d checkout events, for %d recorded checkouts", checkouts, eventRows, calls.Load())
+	}
+	if want := len(writerNames()) * writerCheckouts; calls.Load() != int64(want) {
+		t.Errorf("%d recorded checkouts, want %d", calls.Load(), want)
+	}
+}
+
+func writerNames() []string {
+	var names []string
+	for g := 0; g < writerGoroutines; g++ {
+		names = append(names, fmt.Sprintf("goroutine-%d", g))
+	}
+	for p := 0; p < writerProcesses; p++ {
+		names = append(names, fmt.Sprintf("process-%d", p))
+	}
+	return names
+}
+
+func checkoutBranch(writer string, i int) string {
+	return writer + "/branch-" + strconv.Itoa(i)
+}
+
+// Record the checkouts of a writer with its own client, as a process of its own does,
+// returning the number of checkouts recorded
+func recordCheckouts(dir string, writer string) (int, error) {
+	client, err := Open(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	return recordCheckoutsWith(client, writer)
+}
+
+func recordCheckoutsWith(client *Client, writer string) (int, error) {
+	for i := 0; i < writerCheckouts; i++ {
+		branch := checkoutBranch(writer, i)
+		if err := client.RecordCheckout(branch, "Commit of "+branch, false); err != nil {
+			return i, fmt.Errorf("error recording checkout of %s: %w", branch, err)
+		}
+	}
+	return writerCheckouts, nil
+}
+
+// Record the checkouts of a writer from a process of its own, running the test binary,
+// which prints the number of checkouts recorded
+func recordCheckoutsInProcess(dir string, writer string) (int, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), recordDirEnv+"="+dir, recordWriterEnv+"="+writer)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	recorded, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return recorded, fmt.Errorf("%s failed: %w: %s", writer, err, stderr.String())
+	}
+	return recorded, nil
+}
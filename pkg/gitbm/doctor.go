@@ -0,0 +1,253 @@
+package gitbm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/db"
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// Statuses of a diagnosis
+const (
+	DiagnosisOK      = "ok"
+	DiagnosisWarning = "warning" // Something works, but not as well as it could
+	DiagnosisError   = "error"   // Something is broken
+)
+
+// The result of a check of Diagnose
+type Diagnosis struct {
+	Check    string // Name of the check, eg. "hook"
+	Status   string
+	Detail   string       // What the check found
+	Remedies []string     // How to fix the problem by hand
+	Fix      func() error // Fixes the problem, nil if it can not be fixed safely
+	FixInfo  string       // What Fix does
+}
+
+// Check the setup and the database of the repository: how checkouts are tracked, whether
+// the hook is installed where git runs it, and the consistency of the database.
+// Diagnoses with a Fix can be repaired without losing data.
+func (c *Client) Diagnose() []Diagnosis {
+	diagnoses := c.diagnoseTracking()
+	diagnoses = append(diagnoses,
+		c.diagnoseIntegrity(),
+		c.diagnoseForeignKeys(),
+		c.diagnoseCurrentGroup(),
+		c.diagnoseMissingBranches(),
+	)
+	return diagnoses
+}
+
+func failed(check string, err error) Diagnosis {
+	return Diagnosis{Check: check, Status: DiagnosisError, Detail: err.Error()}
+}
+
+// Check that the hook is installed and current where git runs hooks from, and only if it tracks checkouts
+func (c *Client) diagnoseTracking() []Diagnosis {
+	tracking := c.Tracking()
+	diagnoses := []Diagnosis{{Check: "tracking", Status: DiagnosisOK, Detail: "checkouts are tracked with: " + tracking}}
+
+	hooksDir, err := c.git.GetGitHooksDir()
+	if err != nil {
+		return append(diagnoses, failed("hooks path", err))
+	}
+	state, err := c.git.GetHookState()
+	if err != nil {
+		return append(diagnoses, failed("hook", err))
+	}
+
+	if tracking != TrackingHook {
+		if state == gitutils.HookInstalled || state == gitutils.HookOutdated {
+			diagnoses = append(diagnoses, Diagnosis{
+				Check:   "hook",
+				Status:  DiagnosisWarning,
+				Detail:  fmt.Sprintf("the gitbm hook is installed although checkouts are tracked with: %s", tracking),
+				Fix:     c.git.RemoveGitHook,
+				FixInfo: "remove the hook",
+			})
+		}
+		return diagnoses
+	}
+
+	hooksPath := Diagnosis{Check: "hooks path", Status: DiagnosisOK, Detail: "hooks run from " + hooksDir}
+	gitDir := gitutils.GetGitDir(c.dir)
+	// The hook can only be installed safely in the git directory, or in a directory outside the working tree
+	installable := isWithin(hooksDir, gitDir) || !isWithin(hooksDir, c.dir)
+	if value, ok := c.git.GetGitConfig("core.hooksPath"); ok {
+		hooksPath.Detail = fmt.Sprintf("core.hooksPath is %s, hooks run from %s", value, hooksDir)
+		switch {
+		case isFile(hooksDir):
+			hooksPath.Status = DiagnosisError
+			hooksPath.Detail = fmt.Sprintf("core.hooksPath is %s, hooks are disabled", value)
+			hooksPath.Remedies = []string{"Track checkouts without the hook: gitbm init --tracking=watch"}
+			installable = false
+		case !installable:
+			hooksPath.Status = DiagnosisWarning
+			hooksPath.Remedies = []string{
+				"The hooks are part of the repository, likely managed by a hook manager. Run gitbm from its post-checkout hook",
+				"Or track checkouts without the hook: gitbm init --tracking=watch",
+			}
+		case !isWithin(hooksDir, gitDir):
+			hooksPath.Status = DiagnosisWarning
+			hooksPath.Detail += ", the gitbm hook runs in every repository using them"
+		}
+	}
+	diagnoses = append(diagnoses, hooksPath)
+
+	hook := Diagnosis{Check: "hook", Status: DiagnosisOK, Detail: "installed and up to date"}
+	switch state {
+	case gitutils.HookMissing:
+		hook.Status = DiagnosisError
+		hook.Detail = "the post-checkout hook is not installed in " + hooksDir
+	case gitutils.HookOutdated:
+		hook.Status = DiagnosisWarning
+		hook.Detail = "the post-checkout hook is an older or modified version of the gitbm hook"
+	case gitutils.HookForeign:
+		hook.Status = DiagnosisError
+		hook.Detail = "another post-checkout hook is installed in " + hooksDir
+		hook.Remedies = []string{
+			"Run gitbm from it: gitbm track-checkout \"$(git symbolic-ref --short HEAD)\" \"$(git log -1 --format=%s)\"",
+			"Or track checkouts without the hook: gitbm init --tracking=watch",
+		}
+	}
+	if hook.Status != DiagnosisOK && state != gitutils.HookForeign {
+		if installable {
+			hook.Fix = c.git.InstallGitHook
+			hook.FixInfo = "install the hook of this version of gitbm"
+		} else {
+			hook.Remedies = append(hook.Remedies, "Track checkouts without the hook: gitbm init --tracking=watch")
+		}
+	}
+	return append(diagnoses, hook)
+}
+
+// Check if path exists and is not a directory, eg. a hooks path of /dev/null to disable hooks
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Check if path is dir or inside it
+func isWithin(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (c *Client) diagnoseIntegrity() Diagnosis {
+	problems, err := db.CheckIntegrity(c.db)
+	if err != nil {
+		return failed("database integrity", err)
+	}
+	if len(problems) > 0 {
+		return Diagnosis{
+			Check:  "database integrity",
+			Status: DiagnosisError,
+			Detail: fmt.Sprintf("the database is corrupted: %s", strings.Join(problems, "; ")),
+			Remedies: []string{
+				"Restore a backup taken before the corruption: gitbm backup list, then gitbm restore <id>",
+				"Or start over with an empty database: gitbm destroy, then gitbm init",
+			},
+		}
+	}
+	return Diagnosis{Check: "database integrity", Status: DiagnosisOK, Detail: "ok"}
+}
+
+func (c *Client) diagnoseForeignKeys() Diagnosis {
+	violations, err := db.CheckForeignKeys(c.db)
+	if err != nil {
+		return failed("foreign keys", err)
+	}
+
+	// The current bookmark group is checked on its own
+	var orphans []db.ForeignKeyViolation
+	tables := make(map[string]int)
+	for _, v := range violations {
+		if v.Table == "current_bookmark_group" {
+			continue
+		}
+		orphans = append(orphans, v)
+		tables[v.Table]++
+	}
+	if len(orphans) == 0 {
+		return Diagnosis{Check: "foreign keys", Status: DiagnosisOK, Detail: "ok"}
+	}
+
+	var counts []string
+	for table, count := range tables {
+		counts = append(counts, fmt.Sprintf("%d in %s", count, table))
+	}
+	sort.Strings(counts)
+	return Diagnosis{
+		Check:   "foreign keys",
+		Status:  DiagnosisWarning,
+		Detail:  fmt.Sprintf("%d rows reference rows that no longer exist (%s)", len(orphans), strings.Join(counts, ", ")),
+		Fix:     func() error { return db.DeleteViolations(c.db, orphans) },
+		FixInfo: "delete these rows",
+	}
+}
+
+func (c *Client) diagnoseCurrentGroup() Diagnosis {
+	currentRepo := models.NewCurrentBookmarkGroupRepository(c.conn())
+	id, err := currentRepo.GetCurrentBookmarkGroupId()
+	if err != nil {
+		return failed("current group", err)
+	}
+
+	groups, err := c.Groups()
+	if err != nil {
+		return failed("current group", err)
+	}
+	for _, bg := range groups {
+		if bg.ID == id {
+			return Diagnosis{Check: "current group", Status: DiagnosisOK, Detail: bg.Name}
+		}
+	}
+
+	if id == 0 {
+		return Diagnosis{Check: "current group", Status: DiagnosisOK, Detail: "none set yet"}
+	}
+
+	// Switch to the first group left, or unset the current group if there is none
+	d := Diagnosis{Check: "current group", Status: DiagnosisError, Detail: fmt.Sprintf("the current bookmark group (id %d) no longer exists", id)}
+	if len(groups) > 0 {
+		d.Fix = func() error { return c.setCurrentGroup(groups[0].ID) }
+		d.FixInfo = "switch to " + groups[0].Name
+	} else {
+		d.Fix = currentRepo.Clear
+		d.FixInfo = "unset the current group"
+	}
+	return d
+}
+
+func (c *Client) diagnoseMissingBranches() Diagnosis {
+	candidates, err := c.FindStale(PruneOptions{CheckGit: true})
+	if err != nil {
+		return failed("bookmarks", err)
+	}
+
+	var missing []string
+	for _, candidate := range candidates {
+		if candidate.Reason == "deleted" && len(candidate.Bookmarks) > 0 {
+			missing = append(missing, candidate.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return Diagnosis{Check: "bookmarks", Status: DiagnosisOK, Detail: "all bookmarked branches exist"}
+	}
+
+	// Removing bookmarks loses their aliases and notes, and the branches may just not be fetched yet
+	return Diagnosis{
+		Check:  "bookmarks",
+		Status: DiagnosisWarning,
+		Detail: fmt.Sprintf("%d bookmarked branches no longer exist: %s", len(missing), strings.Join(missing, ", ")),
+		Remedies: []string{
+			"If they exist on a remote, fetch them: git fetch",
+			"Otherwise remove their bookmarks: gitbm prune",
+		},
+	}
+}
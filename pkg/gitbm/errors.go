@@ -0,0 +1,70 @@
+package gitbm
+
+import (
+	"errors"
+	"fmt"
+
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+)
+
+// Errors returned by the client, possibly wrapped with details. Check them with errors.Is.
+var (
+	ErrNotGitRepo         = errors.New("not a git repository")
+	ErrNotInitialized     = errors.New("gitbm is not initialized for this repository, run 'gitbm init' to initialize it")
+	ErrAlreadyInitialized = errors.New("gitbm is already initialized for this repository")
+	ErrNoCurrentGroup     = errors.New("no bookmark group set, use `gitbm switch` to set one")
+	ErrGroupNotFound      = errors.New("bookmark group not found")
+	ErrGroupExists        = errors.New("bookmark group with the same name already exists")
+	ErrBookmarkNotFound   = errors.New("branch not found in the bookmark group")
+	ErrBookmarkExists     = errors.New("branch already exists in the bookmark group")
+	ErrSmartGroup         = errors.New("bookmarks of smart bookmark groups can not be changed")
+	ErrNoHistory          = errors.New("nothing to go to in the navigation history")
+	ErrNothingToUndo      = errors.New("nothing to undo")
+	ErrNothingToRedo      = errors.New("nothing to redo")
+)
+
+// Error evaluating the rule of a smart bookmark group
+type SmartGroupError struct {
+	Group string
+	Err   error
+}
+
+func (e *SmartGroupError) Error() string {
+	return fmt.Sprintf("smart bookmark group %s: %v", e.Group, e.Err)
+}
+
+func (e *SmartGroupError) Unwrap() error {
+	return e.Err
+}
+
+// Error undoing or redoing an operation of the undo log which conflicts with a change made
+// outside of it, eg. by a version of gitbm without the undo log, or by a skipped operation
+type ConflictError struct {
+	Operation Operation
+	Undo      bool   // Whether the operation was being undone or redone
+	Reason    string // What conflicts, eg. a bookmark group with the same name exists
+}
+
+func (e *ConflictError) Error() string {
+	action := "redo"
+	if e.Undo {
+		action = "undo"
+	}
+	return fmt.Sprintf("can not %s #%d (%s): %s", action, e.Operation.ID, e.Operation.Description, e.Reason)
+}
+
+// Failures of git commands run by the client, eg. by Checkout. Check them with errors.As:
+// all but GitCommandError come with suggested remedies, and all wrap a GitCommandError
+// holding what git printed.
+type (
+	GitCommandError       = gitutils.CommandError
+	DirtyTreeError        = gitutils.DirtyTreeError
+	UnknownRefError       = gitutils.UnknownRefError
+	WorktreeConflictError = gitutils.WorktreeConflictError
+	IndexLockedError      = gitutils.IndexLockedError
+)
+
+// Get the suggested remedies of a git failure, nil if there are none
+func Remedies(err error) []string {
+	return gitutils.Remedies(err)
+}
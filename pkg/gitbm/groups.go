@@ -0,0 +1,167 @@
+package gitbm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	grouputils "github.com/devadathanmb/gitbm/internal/utils/groupUtils"
+	"github.com/mattn/go-sqlite3"
+)
+
+// List all bookmark groups
+func (c *Client) Groups() ([]Group, error) {
+	return models.NewBookmarkGroupRepository(c.conn()).List()
+}
+
+// Get a bookmark group by name, or the current bookmark group if name is empty
+func (c *Client) Group(name string) (*Group, error) {
+	if name == "" {
+		return c.CurrentGroup()
+	}
+
+	groups, err := c.Groups()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return &g, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, name)
+}
+
+// Get the current bookmark group, ErrNoCurrentGroup if none is set
+func (c *Client) CurrentGroup() (*Group, error) {
+	currentBookmarkGroupId, err := models.NewCurrentBookmarkGroupRepository(c.conn()).GetCurrentBookmarkGroupId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current bookmark group id: %w", err)
+	}
+	if currentBookmarkGroupId == 0 {
+		return nil, ErrNoCurrentGroup
+	}
+	return models.NewBookmarkGroupRepository(c.conn()).GetByID(currentBookmarkGroupId)
+}
+
+// Create a bookmark group and make it the current one. With a rule type (eg. RuleGlob),
+// it is a smart group of the branches matching the rule.
+func (c *Client) CreateGroup(name string, ruleType string, rule string) (*Group, error) {
+	var bg *Group
+	err := c.inTx(func(c *Client) (err error) {
+		bg, err = c.createGroup(name, ruleType, rule)
+		return err
+	})
+	return bg, err
+}
+
+func (c *Client) createGroup(name string, ruleType string, rule string) (*Group, error) {
+	bg := &Group{Name: name, RuleType: ruleType, Rule: rule}
+	if bg.IsSmart() {
+		if err := grouputils.ValidateRule(c.git, ruleType, rule); err != nil {
+			return nil, err
+		}
+	}
+
+	previous, err := c.currentGroupName()
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.NewBookmarkGroupRepository(c.conn()).Create(bg)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return nil, fmt.Errorf("%w: %s", ErrGroupExists, name)
+		}
+		return nil, fmt.Errorf("error creating bookmark group: %w", err)
+	}
+
+	return bg, c.record("create", "Create bookmark group "+name, step{
+		Kind:     stepCreateGroup,
+		Group:    &groupSnapshot{Name: name, RuleType: ruleType, Rule: rule},
+		Current:  true,
+		Previous: previous,
+	})
+}
+
+// Delete a bookmark group along with its bookmarks. If it was the current group, none is current anymore.
+func (c *Client) DeleteGroup(name string) error {
+	return c.inTx(func(c *Client) error {
+		return c.deleteGroup(name)
+	})
+}
+
+func (c *Client) deleteGroup(name string) error {
+	bg, err := c.Group(name)
+	if err != nil {
+		return err
+	}
+
+	// Snapshot the group and its bookmarks, which are deleted along with it, to undo it
+	snapshot, err := c.snapshotGroup(*bg)
+	if err != nil {
+		return err
+	}
+	current, err := c.currentGroupName()
+	if err != nil {
+		return err
+	}
+
+	if err := models.NewBookmarkGroupRepository(c.conn()).Delete(name); err != nil {
+		return fmt.Errorf("error deleting bookmark group: %w", err)
+	}
+
+	description := "Delete bookmark group " + name
+	if len(snapshot.Bookmarks) > 0 {
+		description += fmt.Sprintf(" and its %d bookmarks", len(snapshot.Bookmarks))
+	}
+	return c.record("delete", description, step{Kind: stepDeleteGroup, Group: snapshot, Current: current == name})
+}
+
+// Make a bookmark group the current one
+func (c *Client) SwitchGroup(name string) (*Group, error) {
+	var bg *Group
+	err := c.inTx(func(c *Client) (err error) {
+		bg, err = c.switchGroup(name)
+		return err
+	})
+	return bg, err
+}
+
+func (c *Client) switchGroup(name string) (*Group, error) {
+	bg, err := c.Group(name)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := c.currentGroupName()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.setCurrentGroup(bg.ID); err != nil {
+		return nil, err
+	}
+	if previous == bg.Name {
+		return bg, nil
+	}
+
+	description := "Switch to bookmark group " + bg.Name
+	if previous != "" {
+		description = fmt.Sprintf("Switch from bookmark group %s to %s", previous, bg.Name)
+	}
+	return bg, c.record("switch", description, step{Kind: stepSwitchGroup, From: previous, To: bg.Name})
+}
+
+func (c *Client) setCurrentGroup(id int64) error {
+	err := models.NewCurrentBookmarkGroupRepository(c.conn()).SetCurrentBookmarkGroupId(id)
+	if err != nil {
+		return fmt.Errorf("error setting current bookmark group: %w", err)
+	}
+	return nil
+}
+
+// Describe the rule of a smart bookmark group for display, eg. "merged into main"
+func DescribeRule(bg Group) string {
+	return grouputils.DescribeRule(bg)
+}
@@ -0,0 +1,162 @@
+package gitbm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+)
+
+// List the most recently checked out branches, or the least recent ones if reverse is set
+func (c *Client) RecentBranches(limit int, reverse bool) ([]BranchStats, error) {
+	return models.NewBranchCheckoutRepository(c.conn()).GetRecent(limit, reverse)
+}
+
+// List the most frequently checked out branches, or the least frequent ones if reverse is set
+func (c *Client) FrequentBranches(limit int, reverse bool) ([]BranchStats, error) {
+	return models.NewBranchCheckoutRepository(c.conn()).GetFrequent(limit, reverse)
+}
+
+// List the recently checked out branches ordered by how often they were checked out
+func (c *Client) RecentFrequentBranches(limit int, reverse bool) ([]BranchStats, error) {
+	return models.NewBranchCheckoutRepository(c.conn()).GetRecentFrequent(limit, reverse)
+}
+
+// Clear the checkout stats of all branches
+func (c *Client) ResetCheckouts() error {
+	return c.inTx(func(c *Client) error {
+		return c.resetCheckouts()
+	})
+}
+
+func (c *Client) resetCheckouts() error {
+	repo := models.NewBranchCheckoutRepository(c.conn())
+	checkouts, err := repo.ListAll()
+	if err != nil {
+		return err
+	}
+
+	if err := repo.DeleteAll(); err != nil {
+		return err
+	}
+	if len(checkouts) == 0 {
+		return nil
+	}
+	return c.record("reset", fmt.Sprintf("Reset the checkouts of %d branches", len(checkouts)), step{Kind: stepRemoveCheckouts, Checkouts: checkouts})
+}
+
+// Get the back/forward navigation history, oldest first, along with the ID of the current entry
+func (c *Client) NavigationHistory() ([]NavigationEntry, int64, error) {
+	return models.NewNavigationRepository(c.conn()).List()
+}
+
+// List the checkout events between since and until, oldest first
+func (c *Client) CheckoutEvents(since time.Time, until time.Time) ([]CheckoutEvent, error) {
+	return models.NewCheckoutEventRepository(c.conn()).ListBetween(since, until)
+}
+
+// List the checkout events recorded after the event with the given ID, oldest first.
+// Along with LastCheckoutEventID, this allows following checkouts as they happen.
+func (c *Client) CheckoutEventsAfter(id int64) ([]CheckoutEvent, error) {
+	return models.NewCheckoutEventRepository(c.conn()).ListAfter(id)
+}
+
+// Get the ID of the last recorded checkout event, 0 if there is none
+func (c *Client) LastCheckoutEventID() (int64, error) {
+	return models.NewCheckoutEventRepository(c.conn()).GetLastID()
+}
+
+// Record a checkout of branch, whose last commit has the given subject: update its checkout stats,
+// log the checkout event and, unless navigating, push the branch onto the navigation history.
+// This is what the post-checkout hook does; navigating is set for checkouts done by Jump.
+func (c *Client) RecordCheckout(branch string, commitMsg string, navigating bool) error {
+	return c.recordCheckout(branch, commitMsg, navigating, models.CheckoutSourceHook)
+}
+
+// Record a checkout in one transaction, so that processes recording checkouts at the same time,
+// eg. the hooks of a scripted rebase, neither fail nor leave part of a checkout unrecorded
+func (c *Client) recordCheckout(branch string, commitMsg string, navigating bool, source string) error {
+	return c.inTx(func(c *Client) error {
+		err := models.NewBranchCheckoutRepository(c.conn()).Upsert(&models.BranchCheckout{
+			Name:            branch,
+			LatestCommitMsg: commitMsg,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Log the checkout to compute the time spent on each branch
+		err = models.NewCheckoutEventRepository(c.conn()).Create(&models.CheckoutEvent{BranchName: branch, Source: source})
+		if err != nil {
+			return err
+		}
+
+		// Checkouts done by back/forward only move the navigation cursor
+		if navigating {
+			return nil
+		}
+
+		navigationRepo := models.NewNavigationRepository(c.conn())
+
+		// Seed an empty navigation history with the branch we came from,
+		// so that going back works right after the first tracked checkout
+		entries, _, err := navigationRepo.List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			previousBranch, err := c.git.GetPreviousGitBranch()
+			if err == nil && previousBranch != "" && previousBranch != branch {
+				if err := navigationRepo.Push(previousBranch); err != nil {
+					return err
+				}
+			}
+		}
+
+		return navigationRepo.Push(branch)
+	})
+}
+
+// Import the branch checkouts of the HEAD reflog into the checkout history,
+// returning the number of imported checkouts. Importing again is safe.
+func (c *Client) ImportReflog() (int, error) {
+	reflogCheckouts, err := c.git.ReadCheckoutReflog()
+	if err != nil {
+		return 0, err
+	}
+
+	// Checkouts tracked by the hook or gitbm watch are in the reflog too
+	checkoutEventRepo := models.NewCheckoutEventRepository(c.conn())
+	firstTrackedAt, err := checkoutEventRepo.GetFirstTrackedAt()
+	if err != nil {
+		return 0, err
+	}
+
+	// Branches that no longer exist would only clutter the pickers
+	branches, err := c.git.ListBranches()
+	if err != nil {
+		return 0, err
+	}
+	commitMsgs := make(map[string]string, len(branches))
+	for _, b := range branches {
+		commitMsgs[b.Name] = b.Subject
+	}
+
+	var events []models.CheckoutEvent
+	for _, checkout := range reflogCheckouts {
+		if firstTrackedAt != nil && !checkout.At.Before(*firstTrackedAt) {
+			continue
+		}
+		if _, ok := commitMsgs[checkout.To]; !ok || checkout.From == checkout.To {
+			continue
+		}
+
+		events = append(events, models.CheckoutEvent{
+			BranchName:   checkout.To,
+			CheckedOutAt: checkout.At,
+			Source:       models.CheckoutSourceReflog,
+		})
+	}
+
+	return checkoutEventRepo.Import(events, commitMsgs)
+}
@@ -0,0 +1,83 @@
+package gitbm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	pruneutils "github.com/devadathanmb/gitbm/internal/utils/pruneUtils"
+)
+
+// A stale branch, with its bookmarks and checkout stats that can be pruned
+type PruneCandidate = pruneutils.Candidate
+
+// What makes a branch stale, see FindStale
+type PruneOptions = pruneutils.Options
+
+// Find the stale branches among the bookmarks of every group and the checkout stats.
+// A branch is stale when it no longer exists locally nor on any remote, when it is fully merged
+// into the default branch (both only if opts.CheckGit is set), or when it was neither checked out
+// nor bookmarked for opts.InactiveAfter.
+func (c *Client) FindStale(opts PruneOptions) ([]PruneCandidate, error) {
+	bookmarks, err := models.NewBranchRepository(c.conn()).ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	checkouts, err := models.NewBranchCheckoutRepository(c.conn()).ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return pruneutils.FindStale(c.git, bookmarks, checkouts, opts, time.Now())
+}
+
+// Remove the bookmarks and checkout stats of the candidates in one transaction
+func (c *Client) Prune(candidates []PruneCandidate) error {
+	return c.inTx(func(c *Client) error {
+		return c.prune(candidates)
+	})
+}
+
+func (c *Client) prune(candidates []PruneCandidate) error {
+	var branchIDs []int64
+	var checkoutNames []string
+	for _, candidate := range candidates {
+		for _, b := range candidate.Bookmarks {
+			branchIDs = append(branchIDs, b.ID)
+		}
+		if candidate.Checkout != nil {
+			checkoutNames = append(checkoutNames, candidate.Name)
+		}
+	}
+
+	// Snapshot what is pruned to undo it, bookmarks with their labels
+	groupNames, err := c.GroupNames()
+	if err != nil {
+		return err
+	}
+	var steps []step
+	var checkouts []BranchStats
+	for _, candidate := range candidates {
+		if err := c.loadTags(candidate.Bookmarks); err != nil {
+			return err
+		}
+		for _, b := range candidate.Bookmarks {
+			steps = append(steps, step{Kind: stepRemoveBookmark, Bookmark: snapshotBookmark(b, groupNames[b.BookmarkGroupID])})
+		}
+		if candidate.Checkout != nil {
+			checkouts = append(checkouts, *candidate.Checkout)
+		}
+	}
+	if len(checkouts) > 0 {
+		steps = append(steps, step{Kind: stepRemoveCheckouts, Checkouts: checkouts})
+	}
+
+	if err := models.NewBranchRepository(c.conn()).Prune(branchIDs, checkoutNames); err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	return c.record("prune", fmt.Sprintf("Prune %d stale branches", len(candidates)), steps...)
+}
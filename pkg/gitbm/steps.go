@@ -0,0 +1,373 @@
+package gitbm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+)
+
+// Kinds of changes recorded in the undo log
+const (
+	stepCreateGroup      = "createGroup"
+	stepDeleteGroup      = "deleteGroup"
+	stepAddBookmark      = "addBookmark"
+	stepRemoveBookmark   = "removeBookmark"
+	stepSwitchGroup      = "switchGroup"
+	stepSetNotes         = "setNotes"
+	stepAddTag           = "addTag"
+	stepRemoveTag        = "removeTag"
+	stepRemoveCheckouts  = "removeCheckouts"
+	stepRestoreCheckouts = "restoreCheckouts"
+)
+
+// The change reverting each kind of change
+var inverseSteps = map[string]string{
+	stepCreateGroup:      stepDeleteGroup,
+	stepDeleteGroup:      stepCreateGroup,
+	stepAddBookmark:      stepRemoveBookmark,
+	stepRemoveBookmark:   stepAddBookmark,
+	stepSwitchGroup:      stepSwitchGroup,
+	stepSetNotes:         stepSetNotes,
+	stepAddTag:           stepRemoveTag,
+	stepRemoveTag:        stepAddTag,
+	stepRemoveCheckouts:  stepRestoreCheckouts,
+	stepRestoreCheckouts: stepRemoveCheckouts,
+}
+
+// A bookmark as recorded in the undo log. It refers to its group by name,
+// as IDs change when a group or a bookmark is created again.
+type bookmarkSnapshot struct {
+	Group  string   `json:"group"`
+	Name   string   `json:"name"`
+	Alias  string   `json:"alias,omitempty"`
+	Notes  string   `json:"notes,omitempty"`
+	Remote string   `json:"remote,omitempty"`
+	Kind   string   `json:"kind,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// A bookmark group as recorded in the undo log, with its bookmarks
+type groupSnapshot struct {
+	Name      string             `json:"name"`
+	RuleType  string             `json:"ruleType,omitempty"`
+	Rule      string             `json:"rule,omitempty"`
+	Bookmarks []bookmarkSnapshot `json:"bookmarks,omitempty"`
+}
+
+// A change made by an operation, with what it takes to apply it again or revert it
+type step struct {
+	Kind string `json:"kind"`
+
+	// createGroup and deleteGroup: the group, whether it is the current group
+	// while it exists, and the current group while it does not
+	Group    *groupSnapshot `json:"group,omitempty"`
+	Current  bool           `json:"current,omitempty"`
+	Previous string         `json:"previous,omitempty"`
+
+	// addBookmark, removeBookmark, setNotes, addTag and removeTag
+	Bookmark *bookmarkSnapshot `json:"bookmark,omitempty"`
+
+	// switchGroup: the names of the groups, setNotes: the notes
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	Tag string `json:"tag,omitempty"`
+
+	// removeCheckouts and restoreCheckouts
+	Checkouts []BranchStats `json:"checkouts,omitempty"`
+}
+
+// Get the step reverting s
+func (s step) inverse() step {
+	inverse := s
+	inverse.Kind = inverseSteps[s.Kind]
+	inverse.From, inverse.To = s.To, s.From
+	return inverse
+}
+
+func snapshotBookmark(b Bookmark, group string) *bookmarkSnapshot {
+	return &bookmarkSnapshot{
+		Group:  group,
+		Name:   b.Name,
+		Alias:  b.Alias,
+		Notes:  b.Notes,
+		Remote: b.Remote,
+		Kind:   b.Kind,
+		Tags:   b.Tags,
+	}
+}
+
+// Snapshot a bookmark group along with its bookmarks and their labels
+func (c *Client) snapshotGroup(bg Group) (*groupSnapshot, error) {
+	snapshot := &groupSnapshot{Name: bg.Name, RuleType: bg.RuleType, Rule: bg.Rule}
+	if bg.IsSmart() {
+		return snapshot, nil
+	}
+
+	bookmarks, err := models.NewBranchRepository(c.conn()).ListByBookmarkGroupId(bg.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.loadTags(bookmarks); err != nil {
+		return nil, err
+	}
+	for _, b := range bookmarks {
+		snapshot.Bookmarks = append(snapshot.Bookmarks, *snapshotBookmark(b, bg.Name))
+	}
+	return snapshot, nil
+}
+
+// Get the stored bookmark of a branch with its labels, to snapshot it
+func (c *Client) storedBookmark(groupID int64, name string) (*Bookmark, error) {
+	b, err := models.NewBranchRepository(c.conn()).GetByName(groupID, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBookmarkNotFound, name)
+	}
+	return b, c.reloadTags(b)
+}
+
+// Get the name of the current bookmark group, empty if none is set or it no longer exists
+func (c *Client) currentGroupName() (string, error) {
+	id, err := models.NewCurrentBookmarkGroupRepository(c.conn()).GetCurrentBookmarkGroupId()
+	if err != nil {
+		return "", err
+	}
+	groups, err := c.Groups()
+	if err != nil {
+		return "", err
+	}
+	for _, bg := range groups {
+		if bg.ID == id {
+			return bg.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// Get a bookmark group by name, nil if it does not exist
+func (c *Client) findGroup(name string) (*Group, error) {
+	groups, err := c.Groups()
+	if err != nil {
+		return nil, err
+	}
+	for _, bg := range groups {
+		if bg.Name == name {
+			return &bg, nil
+		}
+	}
+	return nil, nil
+}
+
+// Get the stored bookmark of a snapshot, nil if it or its group does not exist
+func (c *Client) findBookmark(s bookmarkSnapshot) (*Bookmark, error) {
+	bg, err := c.findGroup(s.Group)
+	if err != nil || bg == nil {
+		return nil, err
+	}
+	bookmarks, err := models.NewBranchRepository(c.conn()).ListByBookmarkGroupId(bg.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bookmarks {
+		if b.Name == s.Name {
+			return &b, nil
+		}
+	}
+	return nil, nil
+}
+
+// Check that a step can be applied to the database as it is, returning why not.
+// The undo log is applied in order, so a step only conflicts with changes made
+// outside of it, or by an operation that was skipped.
+func (c *Client) checkStep(s step) (string, error) {
+	switch s.Kind {
+	case stepCreateGroup:
+		bg, err := c.findGroup(s.Group.Name)
+		if err != nil {
+			return "", err
+		}
+		if bg != nil {
+			return fmt.Sprintf("a bookmark group named %s exists", s.Group.Name), nil
+		}
+
+	case stepDeleteGroup:
+		bg, err := c.findGroup(s.Group.Name)
+		if err != nil {
+			return "", err
+		}
+		if bg == nil {
+			return fmt.Sprintf("bookmark group %s no longer exists", s.Group.Name), nil
+		}
+		// Bookmarks added since would be deleted along with the group
+		bookmarks, err := models.NewBranchRepository(c.conn()).ListByBookmarkGroupId(bg.ID)
+		if err != nil {
+			return "", err
+		}
+		var added []string
+		for _, b := range bookmarks {
+			if !slices.ContainsFunc(s.Group.Bookmarks, func(snapshot bookmarkSnapshot) bool { return snapshot.Name == b.Name }) {
+				added = append(added, b.Name)
+			}
+		}
+		if len(added) > 0 {
+			return fmt.Sprintf("bookmarks were added to %s since: %s", s.Group.Name, strings.Join(added, ", ")), nil
+		}
+
+	case stepAddBookmark:
+		bg, err := c.findGroup(s.Bookmark.Group)
+		if err != nil {
+			return "", err
+		}
+		if bg == nil {
+			return fmt.Sprintf("bookmark group %s no longer exists", s.Bookmark.Group), nil
+		}
+		b, err := c.findBookmark(*s.Bookmark)
+		if err != nil {
+			return "", err
+		}
+		if b != nil {
+			return fmt.Sprintf("%s is bookmarked in %s already", s.Bookmark.Name, s.Bookmark.Group), nil
+		}
+
+	case stepRemoveBookmark, stepSetNotes, stepAddTag, stepRemoveTag:
+		b, err := c.findBookmark(*s.Bookmark)
+		if err != nil {
+			return "", err
+		}
+		if b == nil {
+			return fmt.Sprintf("%s is no longer bookmarked in %s", s.Bookmark.Name, s.Bookmark.Group), nil
+		}
+		if s.Kind == stepSetNotes && b.Notes != s.From {
+			return fmt.Sprintf("the notes of %s in %s changed since", s.Bookmark.Name, s.Bookmark.Group), nil
+		}
+
+	case stepSwitchGroup:
+		if s.To == "" {
+			return "", nil
+		}
+		bg, err := c.findGroup(s.To)
+		if err != nil {
+			return "", err
+		}
+		if bg == nil {
+			return fmt.Sprintf("bookmark group %s no longer exists", s.To), nil
+		}
+	}
+	return "", nil
+}
+
+// Apply a step checked by checkStep
+func (c *Client) applyStep(s step) error {
+	branchRepo := models.NewBranchRepository(c.conn())
+	tagRepo := models.NewTagRepository(c.conn())
+
+	switch s.Kind {
+	case stepCreateGroup:
+		current, err := c.currentGroupName()
+		if err != nil {
+			return err
+		}
+		// Creating a group makes it the current one
+		bg := &Group{Name: s.Group.Name, RuleType: s.Group.RuleType, Rule: s.Group.Rule}
+		if err := models.NewBookmarkGroupRepository(c.conn()).Create(bg); err != nil {
+			return fmt.Errorf("error creating bookmark group: %w", err)
+		}
+		for _, b := range s.Group.Bookmarks {
+			if err := c.restoreBookmark(bg.ID, b); err != nil {
+				return err
+			}
+		}
+		if !s.Current {
+			return c.switchToGroup(current)
+		}
+
+	case stepDeleteGroup:
+		current, err := c.currentGroupName()
+		if err != nil {
+			return err
+		}
+		if err := models.NewBookmarkGroupRepository(c.conn()).Delete(s.Group.Name); err != nil {
+			return fmt.Errorf("error deleting bookmark group: %w", err)
+		}
+		if current == s.Group.Name {
+			return c.switchToGroup(s.Previous)
+		}
+
+	case stepAddBookmark:
+		bg, err := c.findGroup(s.Bookmark.Group)
+		if err != nil {
+			return err
+		}
+		return c.restoreBookmark(bg.ID, *s.Bookmark)
+
+	case stepRemoveBookmark:
+		b, err := c.findBookmark(*s.Bookmark)
+		if err != nil {
+			return err
+		}
+		return branchRepo.Remove(b.BookmarkGroupID, b.Name)
+
+	case stepSetNotes:
+		b, err := c.findBookmark(*s.Bookmark)
+		if err != nil {
+			return err
+		}
+		return branchRepo.UpdateNotes(b.BookmarkGroupID, b.Name, s.To)
+
+	case stepAddTag, stepRemoveTag:
+		b, err := c.findBookmark(*s.Bookmark)
+		if err != nil {
+			return err
+		}
+		if s.Kind == stepAddTag {
+			return tagRepo.AddToBranch(b.ID, s.Tag)
+		}
+		return tagRepo.RemoveFromBranch(b.ID, s.Tag)
+
+	case stepSwitchGroup:
+		return c.switchToGroup(s.To)
+
+	case stepRemoveCheckouts:
+		names := make([]string, 0, len(s.Checkouts))
+		for _, checkout := range s.Checkouts {
+			names = append(names, checkout.Name)
+		}
+		return models.NewBranchCheckoutRepository(c.conn()).DeleteByNames(names)
+
+	case stepRestoreCheckouts:
+		return models.NewBranchCheckoutRepository(c.conn()).Restore(s.Checkouts)
+
+	default:
+		return fmt.Errorf("unknown change in the undo log: %s", s.Kind)
+	}
+	return nil
+}
+
+// Bookmark a branch again as it was when it was snapshot, labels included
+func (c *Client) restoreBookmark(groupID int64, s bookmarkSnapshot) error {
+	b := &Bookmark{BookmarkGroupID: groupID, Name: s.Name, Alias: s.Alias, Notes: s.Notes, Remote: s.Remote, Kind: s.Kind}
+	if err := models.NewBranchRepository(c.conn()).Create(b); err != nil {
+		return err
+	}
+	for _, tag := range s.Tags {
+		if err := models.NewTagRepository(c.conn()).AddToBranch(b.ID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Make a bookmark group the current one by name, or unset the current group if it is
+// empty or no longer exists
+func (c *Client) switchToGroup(name string) error {
+	bg, err := c.findGroup(name)
+	if err != nil {
+		return err
+	}
+	if name == "" || bg == nil {
+		return models.NewCurrentBookmarkGroupRepository(c.conn()).Clear()
+	}
+	return c.setCurrentGroup(bg.ID)
+}
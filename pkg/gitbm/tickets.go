@@ -0,0 +1,35 @@
+package gitbm
+
+import (
+	ticketutils "github.com/devadathanmb/gitbm/internal/utils/ticketUtils"
+)
+
+// A ticket key found in a branch name, with its URL if its tracker has a URL template
+type Ticket = ticketutils.Ticket
+
+// Find the tickets in a branch name, using the trackers of the gitbm.tracker.<name> git config
+func (c *Client) Tickets(branch string) ([]Ticket, error) {
+	trackers, err := ticketutils.LoadTrackers(c.git)
+	if err != nil {
+		return nil, err
+	}
+	return ticketutils.ExtractTickets(branch, trackers), nil
+}
+
+// Fill in the ticket keys found in the names of the bookmarks, using the trackers of the git config
+func (c *Client) LoadTickets(bookmarks []Bookmark) error {
+	trackers, err := ticketutils.LoadTrackers(c.git)
+	if err != nil {
+		return err
+	}
+
+	for i := range bookmarks {
+		bookmarks[i].Tickets = ticketutils.Keys(ticketutils.ExtractTickets(bookmarks[i].Name, trackers))
+	}
+	return nil
+}
+
+// Get the pull request URL of a branch from the gitbm.prUrl git config template
+func (c *Client) PullRequestURL(branch string) (string, error) {
+	return ticketutils.GetPullRequestURL(c.git, branch)
+}
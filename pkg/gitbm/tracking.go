@@ -0,0 +1,53 @@
+package gitbm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Git config key of the way checkouts are tracked
+const TrackingConfigKey = "gitbm.tracking"
+
+// Ways of tracking checkouts, stored in the gitbm.tracking git config
+const (
+	TrackingHook  = "hook"  // The post-checkout hook runs gitbm track-checkout (default)
+	TrackingWatch = "watch" // gitbm watch follows HEAD and the reflog, no hook is installed
+	TrackingNone  = "none"  // Checkouts are not tracked, only bookmarks are managed
+)
+
+// All tracking modes, in the order they are documented
+var TrackingModes = []string{TrackingHook, TrackingWatch, TrackingNone}
+
+func isTrackingMode(mode string) bool {
+	return slices.Contains(TrackingModes, mode)
+}
+
+// Get the way checkouts are tracked, the hook if it was never set
+func (c *Client) Tracking() string {
+	mode, ok := c.git.GetGitConfig(TrackingConfigKey)
+	if !ok || !isTrackingMode(mode) {
+		return TrackingHook
+	}
+	return mode
+}
+
+// Set the way checkouts are tracked: the post-checkout hook is installed for TrackingHook
+// and removed otherwise. Starting gitbm watch for TrackingWatch is up to the caller.
+func (c *Client) SetTracking(mode string) error {
+	if !isTrackingMode(mode) {
+		return fmt.Errorf("invalid tracking mode '%s', must be one of %s", mode, strings.Join(TrackingModes, ", "))
+	}
+
+	if mode == TrackingHook {
+		if err := c.git.InstallGitHook(); err != nil {
+			return fmt.Errorf("error installing gitbm hook: %w", err)
+		}
+	} else {
+		if err := c.git.RemoveGitHook(); err != nil {
+			return fmt.Errorf("error removing gitbm hook: %w", err)
+		}
+	}
+
+	return c.git.SetGitConfig(TrackingConfigKey, mode)
+}
@@ -0,0 +1,183 @@
+package gitbm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+)
+
+// An entry of the undo log: a change made to the bookmarks, the bookmark groups
+// or the checkout stats, see Undo
+type Operation = models.Operation
+
+// States of the operations of the undo log
+const (
+	OperationDone    = models.OperationDone
+	OperationUndone  = models.OperationUndone
+	OperationSkipped = models.OperationSkipped
+)
+
+// List the most recent operations of the undo log, newest first
+func (c *Client) Operations(limit int) ([]Operation, error) {
+	return models.NewOperationRepository(c.conn()).List(limit)
+}
+
+// Undo the most recent operation of the undo log, returning it. It fails with ErrNothingToUndo
+// if there is none, and with a ConflictError if it can no longer be undone, eg. a deleted
+// group was created again since by an older version of gitbm (see SkipUndo).
+func (c *Client) Undo() (*Operation, error) {
+	var op *Operation
+	err := c.inTx(func(c *Client) (err error) {
+		op, err = c.undo()
+		return err
+	})
+	return op, err
+}
+
+func (c *Client) undo() (*Operation, error) {
+	repo := models.NewOperationRepository(c.conn())
+	op, err := repo.GetLastDone()
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		return nil, ErrNothingToUndo
+	}
+
+	steps, err := c.undoSteps(*op)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.applySteps(*op, steps, true); err != nil {
+		return nil, err
+	}
+	return op, repo.SetState(op.ID, OperationUndone)
+}
+
+// Apply again the most recently undone operation, returning it. It fails with ErrNothingToRedo
+// if there is none, as when something changed since the last undo, and with a ConflictError
+// if it can no longer be applied.
+func (c *Client) Redo() (*Operation, error) {
+	var op *Operation
+	err := c.inTx(func(c *Client) (err error) {
+		op, err = c.redo()
+		return err
+	})
+	return op, err
+}
+
+func (c *Client) redo() (*Operation, error) {
+	repo := models.NewOperationRepository(c.conn())
+	op, err := repo.GetFirstUndone()
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		return nil, ErrNothingToRedo
+	}
+
+	steps, err := decodeSteps(*op)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.applySteps(*op, steps, false); err != nil {
+		return nil, err
+	}
+	return op, repo.SetState(op.ID, OperationDone)
+}
+
+// Get the operation Undo would undo, nil if there is none. The error is a ConflictError
+// if it can no longer be undone.
+func (c *Client) NextUndo() (*Operation, error) {
+	op, err := models.NewOperationRepository(c.conn()).GetLastDone()
+	if err != nil || op == nil {
+		return nil, err
+	}
+
+	steps, err := c.undoSteps(*op)
+	if err != nil {
+		return op, err
+	}
+	return op, c.checkSteps(*op, steps, true)
+}
+
+// Leave the most recent operation of the undo log out of Undo and Redo, eg. because it can
+// no longer be undone, so that the operations before it can be undone. It is returned.
+func (c *Client) SkipUndo() (*Operation, error) {
+	repo := models.NewOperationRepository(c.conn())
+	op, err := repo.GetLastDone()
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		return nil, ErrNothingToUndo
+	}
+	return op, repo.SetState(op.ID, OperationSkipped)
+}
+
+// Record an operation in the undo log, once its steps are applied. It runs in the transaction
+// of the operation (see inTx), which is rolled back if the operation can not be recorded.
+func (c *Client) record(command string, description string, steps ...step) error {
+	data, err := json.Marshal(steps)
+	if err == nil {
+		op := &Operation{Command: command, Description: description, Steps: string(data)}
+		err = models.NewOperationRepository(c.conn()).Create(op)
+	}
+	if err != nil {
+		return fmt.Errorf("error adding '%s' to the undo log: %w", description, err)
+	}
+	return nil
+}
+
+func decodeSteps(op Operation) ([]step, error) {
+	var steps []step
+	if err := json.Unmarshal([]byte(op.Steps), &steps); err != nil {
+		return nil, fmt.Errorf("error reading operation #%d: %w", op.ID, err)
+	}
+	return steps, nil
+}
+
+// Get the steps reverting an operation, in reverse order
+func (c *Client) undoSteps(op Operation) ([]step, error) {
+	steps, err := decodeSteps(op)
+	if err != nil {
+		return nil, err
+	}
+	inverse := make([]step, 0, len(steps))
+	for _, s := range slices.Backward(steps) {
+		inverse = append(inverse, s.inverse())
+	}
+	return inverse, nil
+}
+
+// Check every step of an operation before applying any, so that it is applied in full or not at all
+func (c *Client) checkSteps(op Operation, steps []step, undo bool) error {
+	for _, s := range steps {
+		reason, err := c.checkStep(s)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return &ConflictError{Operation: op, Undo: undo, Reason: reason}
+		}
+	}
+	return nil
+}
+
+func (c *Client) applySteps(op Operation, steps []step, undo bool) error {
+	if err := c.checkSteps(op, steps, undo); err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if err := c.applyStep(s); err != nil {
+			action := "redoing"
+			if undo {
+				action = "undoing"
+			}
+			return fmt.Errorf("error %s #%d (%s): %w", action, op.ID, op.Description, err)
+		}
+	}
+	return nil
+}
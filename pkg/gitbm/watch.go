@@ -0,0 +1,132 @@
+package gitbm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/devadathanmb/gitbm/internal/db/models"
+	gitutils "github.com/devadathanmb/gitbm/internal/utils/gitUtils"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// Time to wait after HEAD changes before reading the reflog, so that git is done writing
+	// and the hook has recorded the checkout
+	watchSettleDelay = 300 * time.Millisecond
+
+	// A checkout already recorded by the hook this close in time is not recorded again
+	watchDedupWindow = 5 * time.Second
+)
+
+// Watch the repository for checkouts and record them like the post-checkout hook does,
+// until ctx is done. Unlike the hook, it sees checkouts done by tools that skip hooks.
+// onCheckout is called with the name of every recorded checkout, or with the error recording it.
+func (c *Client) Watch(ctx context.Context, onCheckout func(branch string, err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// HEAD is replaced, not written, on checkout so watch the directories holding it and its reflog
+	gitDir := gitutils.GetGitDir(c.dir)
+	for _, dir := range []string{gitDir, filepath.Join(gitDir, "logs")} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("error watching %s: %w", dir, err)
+		}
+	}
+
+	tail, err := c.git.TailReflog()
+	if err != nil {
+		return err
+	}
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Base(event.Name) == "HEAD" && !event.Has(fsnotify.Chmod) {
+				settle = time.After(watchSettleDelay)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("error watching the repository: %w", err)
+
+		case <-settle:
+			settle = nil
+			c.recordWatchedCheckouts(tail, onCheckout)
+		}
+	}
+}
+
+// Record the checkouts appended to the reflog since the last read
+func (c *Client) recordWatchedCheckouts(tail *gitutils.ReflogTail, onCheckout func(branch string, err error)) {
+	checkouts, err := tail.ReadCheckouts()
+	if err != nil {
+		onCheckout("", err)
+		return
+	}
+
+	// Like the hook, skip the many checkouts done while rebasing or bisecting
+	if len(checkouts) == 0 || c.git.IsRebasingOrBisecting() {
+		return
+	}
+
+	eventRepo := models.NewCheckoutEventRepository(c.conn())
+	recorded := make(map[int64]bool) // Checkouts recorded by the hook, each matching one checkout of the reflog
+	for _, checkout := range checkouts {
+		name := c.checkoutName(checkout)
+
+		// The hook records most checkouts too, maybe several in a row before the reflog is read
+		events, err := eventRepo.ListNear(name, models.CheckoutSourceHook, checkout.At, watchDedupWindow)
+		if err != nil {
+			onCheckout(name, err)
+			continue
+		}
+		if i := slices.IndexFunc(events, func(e models.CheckoutEvent) bool { return !recorded[e.ID] }); i != -1 {
+			recorded[events[i].ID] = true
+			continue
+		}
+
+		subject, _ := c.git.GetCommitSubject(checkout.Commit)
+
+		// Navigations done by Jump need no special care: Jump moves the navigation cursor before
+		// checking out, and pushing the branch the cursor is on is a no-op
+		onCheckout(name, c.recordCheckout(name, subject, false, models.CheckoutSourceWatch))
+	}
+}
+
+// Name a checkout like the hook does: by its branch or, for a detached HEAD, by its tag if it has
+// one and by its abbreviated commit otherwise
+func (c *Client) checkoutName(checkout gitutils.ReflogCheckout) string {
+	if c.git.RefExists("refs/heads/" + checkout.To) {
+		return checkout.To
+	}
+
+	tags, err := c.git.ListTags(checkout.Commit)
+	if err == nil && len(tags) > 0 {
+		return tags[0]
+	}
+
+	short, err := c.git.GetShortCommit(checkout.Commit)
+	if err != nil {
+		return checkout.To
+	}
+	return short
+}
@@ -0,0 +1,57 @@
+#!/usr/bin/env bash
+# Benchmark the exec and go-git backends (gitbm.gitBackend) on a repository with thousands of refs.
+# This times whole gitbm commands, BenchmarkBackend of internal/utils/gitUtils times each backend method.
+#
+# Usage: scripts/bench-git-backend.sh [branches] [runs]
+set -euo pipefail
+
+branches=${1:-5000}
+runs=${2:-5}
+root=$(cd "$(dirname "$0")/.." && pwd)
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+go build -o "$work/gitbm" "$root"
+export PATH="$work:$PATH"
+export GIT_AUTHOR_NAME=bench GIT_AUTHOR_EMAIL=bench@example.com
+export GIT_COMMITTER_NAME=bench GIT_COMMITTER_EMAIL=bench@example.com
+
+repo="$work/repo"
+git init -q -b main "$repo"
+cd "$repo"
+
+# A linear history of 200 commits, with branches pointing all over it
+for i in $(seq 1 200); do
+	git commit -q --allow-empty -m "Commit $i"
+done
+commits=($(git rev-list main))
+for i in $(seq 1 "$branches"); do
+	echo "create refs/heads/feature/JIRA-$i ${commits[$((i % ${#commits[@]}))]}"
+done | git update-ref --stdin
+git gc -q
+
+gitbm init >/dev/null
+gitbm create all --regex 'JIRA-[0-9]+' >/dev/null
+gitbm create merged --merged main >/dev/null
+
+bench() {
+	local start end
+	start=$(date +%s%N)
+	for _ in $(seq 1 "$runs"); do
+		"$@" >/dev/null
+	done
+	end=$(date +%s%N)
+	printf '  %-40s %6d ms/run\n' "$*" $(((end - start) / runs / 1000000))
+}
+
+echo "$branches branches, $runs runs"
+for backend in exec go-git; do
+	git config gitbm.gitBackend "$backend"
+	echo "$backend:"
+	bench gitbm list bookmarks
+	gitbm switch all >/dev/null
+	bench gitbm list branches
+	gitbm switch merged >/dev/null
+	bench gitbm list branches
+	bench gitbm prune --dry-run
+done